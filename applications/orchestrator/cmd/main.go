@@ -21,6 +21,42 @@ func main() {
 		Run: serve.ServeStreamer,
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use: "all-in-one",
+		Run: serve.ServeAllInOne,
+	})
+
+	migrate := &cobra.Command{
+		Use: "migrate",
+	}
+	migrate.AddCommand(&cobra.Command{
+		Use: "up",
+		Run: serve.MigrateUp,
+	})
+	migrate.AddCommand(&cobra.Command{
+		Use: "down",
+		Run: serve.MigrateDown,
+	})
+	migrate.AddCommand(&cobra.Command{
+		Use: "status",
+		Run: serve.MigrateStatus,
+	})
+	migrate.AddCommand(&cobra.Command{
+		Use:  "to <version>",
+		Args: cobra.ExactArgs(1),
+		Run:  serve.MigrateTo,
+	})
+	cmd.AddCommand(migrate)
+
+	schema := &cobra.Command{
+		Use: "schema",
+	}
+	schema.AddCommand(&cobra.Command{
+		Use: "verify",
+		Run: serve.SchemaVerify,
+	})
+	cmd.AddCommand(schema)
+
 	if err := cmd.Execute(); err != nil {
 		logger.Fatalf("failed to execute command: %v", err)
 	}
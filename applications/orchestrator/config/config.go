@@ -5,6 +5,15 @@ import (
 	"github.com/YumikoKawaii/shared/mysql"
 	"github.com/YumikoKawaii/shared/redis"
 	"github.com/YumikoKawaii/shared/tracer"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/receiver"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/relay"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/streamer"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/blobstore"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/database"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/transport"
 	"yumiko_kawaii.com/yine/applications/orchestrator/server"
 )
 
@@ -12,8 +21,17 @@ type Config struct {
 	Server       server.Config
 	Logger       logger.Configuration
 	MysqlCfg     mysql.Config
+	DatabaseCfg  database.Config
 	RedisCfg     redis.Config
 	TracerConfig tracer.Configuration
+	StreamerCfg  streamer.Config
+	RegistryCfg  connection_registry.Config
+	RelayCfg     relay.Config
+	AuthCfg      interceptor.AuthConfig
+	TransportCfg transport.Config
+	ReceiverCfg  receiver.Config
+	BlobStoreCfg blobstore.Config
+	ContentCfg   messagetype.Config
 }
 
 func loadDefaultConfig() *Config {
@@ -27,11 +45,20 @@ func loadDefaultConfig() *Config {
 			Port:     3306,
 			Database: "orchestrator",
 		},
+		DatabaseCfg: database.DefaultConfig(),
 		RedisCfg: redis.Config{
 			Address:       "localhost:6379",
 			EnableTracing: true,
 		},
 		TracerConfig: *tracer.DefaultConfig(),
+		StreamerCfg:  streamer.DefaultConfig(),
+		RegistryCfg:  connection_registry.DefaultConfig(),
+		RelayCfg:     relay.DefaultConfig(),
+		AuthCfg:      interceptor.DefaultAuthConfig(),
+		TransportCfg: transport.DefaultConfig(),
+		ReceiverCfg:  receiver.DefaultConfig(),
+		BlobStoreCfg: blobstore.DefaultConfig(),
+		ContentCfg:   messagetype.DefaultConfig(),
 	}
 	return c
 }
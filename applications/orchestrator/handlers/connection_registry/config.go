@@ -0,0 +1,20 @@
+package connection_registry
+
+import "time"
+
+// Config hold connection registry lease config
+type Config struct {
+	// LeaseTTL is how long a node stays routable after its last heartbeat
+	LeaseTTL          time.Duration `json:"lease_ttl" mapstructure:"lease_ttl" yaml:"lease_ttl"`
+	HeartbeatInterval time.Duration `json:"heartbeat_interval" mapstructure:"heartbeat_interval" yaml:"heartbeat_interval"`
+	SweepInterval     time.Duration `json:"sweep_interval" mapstructure:"sweep_interval" yaml:"sweep_interval"`
+}
+
+// DefaultConfig return a default registry config
+func DefaultConfig() Config {
+	return Config{
+		LeaseTTL:          30 * time.Second,
+		HeartbeatInterval: 10 * time.Second,
+		SweepInterval:     time.Minute,
+	}
+}
@@ -0,0 +1,72 @@
+package connection_registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/google/uuid"
+)
+
+// InstanceId return the node id of this process, a node restarted under the same name before its lease
+// lapsed must not inherit the connection counts of its previous run, those are swept with the old lease
+func InstanceId(nodeId string) string {
+	return nodeId + "-" + uuid.NewString()[:8]
+}
+
+// KeepAlive refreshes the lease of the server right away and then every interval until ctx is done
+func KeepAlive(ctx context.Context, registry Registry, serverIdentification string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := registry.Heartbeat(ctx, serverIdentification); err != nil {
+			logger.WithFields(logger.Fields{
+				"error":   err,
+				"node_id": serverIdentification,
+			}).Errorf("Failed to refresh node lease")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunSweeper removes the nodes with a lapsed lease from the registry every interval until ctx is done,
+// release frees what else is kept for a swept node
+func RunSweeper(ctx context.Context, registry Registry, interval time.Duration, release func(ctx context.Context, server string) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		servers, err := registry.Sweep(ctx)
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"error": err,
+			}).Errorf("Failed to sweep dead nodes")
+			continue
+		}
+		if len(servers) > 0 {
+			logger.WithFields(logger.Fields{
+				"nodes": servers,
+			}).Infof("Swept dead nodes from registry")
+		}
+		for _, server := range servers {
+			if err := release(ctx, server); err != nil {
+				logger.WithFields(logger.Fields{
+					"error":   err,
+					"node_id": server,
+				}).Errorf("Failed to release swept node")
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package connection_registry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// NewMemoryRegistry return a registry kept in process, for a single binary running every service.
+// Servers live as long as the process so heartbeats and sweeps have nothing to do
+func NewMemoryRegistry() Registry {
+	return &memoryImpl{
+		connections: make(map[string]map[string]int),
+	}
+}
+
+// memoryImpl maps user identification to server identification to the number of open connections
+type memoryImpl struct {
+	mu          sync.RWMutex
+	connections map[string]map[string]int
+}
+
+func (i *memoryImpl) Register(_ context.Context, userIdentification string, serverIdentification string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	servers, ok := i.connections[userIdentification]
+	if !ok {
+		servers = make(map[string]int)
+		i.connections[userIdentification] = servers
+	}
+	servers[serverIdentification]++
+	return nil
+}
+
+func (i *memoryImpl) Unregister(_ context.Context, userIdentification string, serverIdentification string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// like the Redis registry, unregistering a connection that was never registered is a no-op
+	servers, ok := i.connections[userIdentification]
+	if !ok {
+		return nil
+	}
+	if _, ok := servers[serverIdentification]; !ok {
+		return nil
+	}
+	servers[serverIdentification]--
+	if servers[serverIdentification] <= 0 {
+		delete(servers, serverIdentification)
+	}
+	if len(servers) == 0 {
+		delete(i.connections, userIdentification)
+	}
+	return nil
+}
+
+func (i *memoryImpl) GetServers(_ context.Context, userIdentifications []string) ([]string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	unique := make(map[string]struct{})
+	for _, id := range userIdentifications {
+		for server := range i.connections[id] {
+			unique[server] = struct{}{}
+		}
+	}
+	return lo.Keys(unique), nil
+}
+
+func (i *memoryImpl) Heartbeat(_ context.Context, _ string) error {
+	return nil
+}
+
+func (i *memoryImpl) Sweep(_ context.Context) ([]string, error) {
+	return make([]string, 0), nil
+}
@@ -2,44 +2,142 @@ package connection_registry
 
 import (
 	"context"
+	"strings"
 
-	"github.com/YumikoKawaii/shared/logger"
 	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
 )
 
 type Registry interface {
 	Register(ctx context.Context, userIdentification string, serverIdentification string) error
+	Unregister(ctx context.Context, userIdentification string, serverIdentification string) error
 	GetServers(ctx context.Context, userIdentifications []string) ([]string, error)
+	Heartbeat(ctx context.Context, serverIdentification string) error
+	Sweep(ctx context.Context) ([]string, error)
 }
 
-func NewRegistry(client *redis.Client) Registry {
+func NewRegistry(client *redis.Client, cfg Config) Registry {
 	return &redisImpl{
 		redisCli: client,
+		cfg:      cfg,
 	}
 }
 
+// unregisterScript decrements the connection count of the server and drops it once no connection is left
+var unregisterScript = redis.NewScript(`
+local count = redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+if count <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+	redis.call('SREM', KEYS[2], ARGV[2])
+end
+return count
+`)
+
+// sweepScript removes a node from the hash of every user it served, unless its lease came back meanwhile
+var sweepScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local users = redis.call('SMEMBERS', KEYS[2])
+for _, user in ipairs(users) do
+	redis.call('HDEL', ARGV[2] .. user, ARGV[1])
+end
+redis.call('DEL', KEYS[2])
+redis.call('SREM', KEYS[3], ARGV[1])
+return 1
+`)
+
+// leasePlaceholder stands for the server identification when splitting the lease key around it
+const leasePlaceholder = "{server}"
+
+// getServersScript return the servers found in the user hashes that still hold a lease, the lease keys are
+// built in the script so the whole lookup is a single round trip
+var getServersScript = redis.NewScript(`
+local seen = {}
+local servers = {}
+for _, key in ipairs(KEYS) do
+	for _, server in ipairs(redis.call('HKEYS', key)) do
+		if not seen[server] then
+			seen[server] = true
+			if redis.call('EXISTS', ARGV[1] .. server .. ARGV[2]) == 1 then
+				table.insert(servers, server)
+			end
+		end
+	end
+end
+return servers
+`)
+
+// redisImpl keeps a hash per user, mapping server identification to the number of open connections.
+// Every server holds a lease refreshed by heartbeat and a set of the users it serves, so that
+// a dead server can be found and removed from the user hashes.
 type redisImpl struct {
 	redisCli *redis.Client
+	cfg      Config
 }
 
 func (i *redisImpl) Register(ctx context.Context, userIdentification string, serverIdentification string) error {
-	return i.redisCli.SAdd(ctx, userIdentification, serverIdentification).Err()
+	_, err := i.redisCli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, constants.GenerateUserConnectionsKey(userIdentification), serverIdentification, 1)
+		pipe.SAdd(ctx, constants.GenerateNodeUsersKey(serverIdentification), userIdentification)
+		pipe.SAdd(ctx, constants.ConnectionsNodesKey, serverIdentification)
+		return nil
+	})
+	return err
 }
 
+func (i *redisImpl) Unregister(ctx context.Context, userIdentification string, serverIdentification string) error {
+	keys := []string{
+		constants.GenerateUserConnectionsKey(userIdentification),
+		constants.GenerateNodeUsersKey(serverIdentification),
+	}
+	return unregisterScript.Run(ctx, i.redisCli, keys, serverIdentification, userIdentification).Err()
+}
+
+// GetServers resolves the live servers of all users in a single script call, each server is returned once
 func (i *redisImpl) GetServers(ctx context.Context, userIdentifications []string) ([]string, error) {
-	servers := make([]string, 0)
+	if len(userIdentifications) == constants.Zero {
+		return make([]string, 0), nil
+	}
 
-	for _, id := range userIdentifications {
-		svs, err := i.redisCli.SMembers(ctx, id).Result()
+	keys := lo.Map(userIdentifications, func(id string, _ int) string {
+		return constants.GenerateUserConnectionsKey(id)
+	})
+	leasePrefix, leaseSuffix, _ := strings.Cut(constants.GenerateNodeLeaseKey(leasePlaceholder), leasePlaceholder)
+	return getServersScript.Run(ctx, i.redisCli, keys, leasePrefix, leaseSuffix).StringSlice()
+}
+
+func (i *redisImpl) Heartbeat(ctx context.Context, serverIdentification string) error {
+	_, err := i.redisCli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, constants.GenerateNodeLeaseKey(serverIdentification), constants.Zero, i.cfg.LeaseTTL)
+		pipe.SAdd(ctx, constants.ConnectionsNodesKey, serverIdentification)
+		return nil
+	})
+	return err
+}
+
+func (i *redisImpl) Sweep(ctx context.Context) ([]string, error) {
+	servers, err := i.redisCli.SMembers(ctx, constants.ConnectionsNodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	swept := make([]string, 0)
+	for _, server := range servers {
+		keys := []string{
+			constants.GenerateNodeLeaseKey(server),
+			constants.GenerateNodeUsersKey(server),
+			constants.ConnectionsNodesKey,
+		}
+		removed, err := sweepScript.Run(ctx, i.redisCli, keys, server, constants.GenerateUserConnectionsKey("")).Int()
 		if err != nil {
-			logger.WithFields(logger.Fields{
-				"error":               err,
-				"user_identification": id,
-			}).Errorf("Failed to get servers from Redis for user, continuing")
-			continue
+			return swept, err
+		}
+		if removed > constants.Zero {
+			swept = append(swept, server)
 		}
-		servers = append(servers, svs...)
 	}
 
-	return servers, nil
+	return swept, nil
 }
@@ -0,0 +1,117 @@
+package connection_registry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+)
+
+func newTestRegistry(tb testing.TB) (*redisImpl, *miniredis.Miniredis) {
+	tb.Helper()
+	server := miniredis.RunT(tb)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	tb.Cleanup(func() { _ = client.Close() })
+	return &redisImpl{redisCli: client, cfg: DefaultConfig()}, server
+}
+
+// seed connects every user to two of the servers, the servers from alive on hold a lease
+func seed(tb testing.TB, registry *redisImpl, users int, servers int, alive int) []string {
+	tb.Helper()
+	ctx := context.Background()
+	userIdentifications := make([]string, 0, users)
+	for u := 0; u < users; u++ {
+		user := fmt.Sprintf("user-%d", u)
+		userIdentifications = append(userIdentifications, user)
+		for _, s := range []int{u % servers, (u + 1) % servers} {
+			if err := registry.Register(ctx, user, fmt.Sprintf("node-%d", s)); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	for s := servers - alive; s < servers; s++ {
+		if err := registry.Heartbeat(ctx, fmt.Sprintf("node-%d", s)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return userIdentifications
+}
+
+func TestGetServers(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+	ctx := context.Background()
+	users := seed(t, registry, 4, 4, 2)
+
+	servers, err := registry.GetServers(ctx, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(servers)
+	if want := []string{"node-2", "node-3"}; !slices.Equal(servers, want) {
+		t.Fatalf("GetServers() = %v, want %v", servers, want)
+	}
+
+	servers, err = registry.GetServers(ctx, []string{"user-0", "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 0 {
+		t.Fatalf("GetServers() = %v, want no server since node-0 and node-1 have no lease", servers)
+	}
+}
+
+// getServersPerUser is the lookup GetServers replaced, a HKEYS round trip per user and a pipeline of EXISTS
+func getServersPerUser(ctx context.Context, client *redis.Client, userIdentifications []string) ([]string, error) {
+	servers := make([]string, 0)
+	for _, id := range userIdentifications {
+		svs, err := client.HKeys(ctx, constants.GenerateUserConnectionsKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, svs...)
+	}
+	servers = lo.Uniq(servers)
+
+	cmds := make([]*redis.IntCmd, len(servers))
+	if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for idx, server := range servers {
+			cmds[idx] = pipe.Exists(ctx, constants.GenerateNodeLeaseKey(server))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return lo.Filter(servers, func(_ string, idx int) bool {
+		return cmds[idx].Val() > constants.Zero
+	}), nil
+}
+
+// BenchmarkGetServers compares the script with the per user loop, miniredis answers over loopback so the
+// round trips saved weigh less than against a remote Redis
+func BenchmarkGetServers(b *testing.B) {
+	for _, members := range []int{10, 100, 1000} {
+		registry, _ := newTestRegistry(b)
+		users := seed(b, registry, members, 20, 15)
+		ctx := context.Background()
+
+		b.Run(fmt.Sprintf("script/%d", members), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := registry.GetServers(ctx, users); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("per_user/%d", members), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := getServersPerUser(ctx, registry.redisCli, users); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
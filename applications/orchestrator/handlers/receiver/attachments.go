@@ -0,0 +1,397 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/blobstore"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+type CreateAttachmentRequest struct {
+	Uploader string `json:"uploader"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+func (r *CreateAttachmentRequest) Validate() error {
+	if r.FileName == "" || len(r.FileName) > 255 {
+		return errors.New("file_name must be between 1 and 255 bytes")
+	}
+	if _, _, err := mime.ParseMediaType(r.MimeType); err != nil {
+		return errors.New("mime_type must be a valid media type")
+	}
+	if r.Size <= 0 {
+		return errors.New("size must be positive")
+	}
+	return nil
+}
+
+type AttachmentRequest struct {
+	AttachmentId int    `json:"attachment_id"`
+	Requester    string `json:"requester"`
+}
+
+// Attachment is the metadata of an uploaded file, the checksum is the hex SHA-256 of the content
+// and the dimensions are set for images
+type Attachment struct {
+	AttachmentId string `json:"attachment_id"`
+	MessageId    string `json:"message_id,omitempty"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"checksum,omitempty"`
+	Width        *int   `json:"width,omitempty"`
+	Height       *int   `json:"height,omitempty"`
+	Status       string `json:"status"`
+}
+
+// AttachmentResponse holds an attachment with the URL to upload or download its content, expiring
+// at UrlExpiresAt in milliseconds. An upload is sent with UrlMethod, a POST as a multipart form holding
+// UrlFields before the file
+type AttachmentResponse struct {
+	Attachment   Attachment        `json:"attachment"`
+	Url          string            `json:"url,omitempty"`
+	UrlMethod    string            `json:"url_method,omitempty"`
+	UrlFields    map[string]string `json:"url_fields,omitempty"`
+	UrlExpiresAt int64             `json:"url_expires_at,omitempty"`
+}
+
+func decodeCreateAttachmentRequest(r *http.Request, _ map[string]string) (interface{}, error) {
+	request := &CreateAttachmentRequest{}
+	return request, server.DecodeJSON(r, request)
+}
+
+func decodeCompleteAttachmentRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &AttachmentRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	request.AttachmentId, err = parseAttachmentId(pathParams)
+	return request, err
+}
+
+func decodeGetAttachmentRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	attachmentId, err := parseAttachmentId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttachmentRequest{
+		AttachmentId: attachmentId,
+		Requester:    r.URL.Query().Get("requester"),
+	}, nil
+}
+
+// CreateAttachment records a pending attachment and return the URL its content is uploaded to,
+// the upload is verified by CompleteAttachment
+func (h *Handler) CreateAttachment(ctx context.Context, request *CreateAttachmentRequest) (*AttachmentResponse, error) {
+	uploader, err := interceptor.Identify(ctx, request.Uploader)
+	if err != nil {
+		return nil, err
+	}
+	if request.Size > h.cfg.MaxAttachmentSize {
+		return nil, status.Errorf(codes.InvalidArgument, "attachments are limited to %d bytes", h.cfg.MaxAttachmentSize)
+	}
+
+	var attachment models.Attachment
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if err := ensureUsers(ctx, store, []string{uploader}); err != nil {
+			return err
+		}
+
+		var err error
+		attachment, err = store.Attachments().Save(ctx, &models.Attachment{
+			Uploader:   uploader,
+			StorageKey: uuid.NewString(),
+			FileName:   request.FileName,
+			MimeType:   request.MimeType,
+			Size:       request.Size,
+			Status:     models.AttachmentStatusPending,
+		})
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":    err,
+			"uploader": uploader,
+		}).Errorf("Failed to create attachment")
+		return nil, err
+	}
+
+	uploadURL, err := h.blobs.UploadURL(ctx, attachment.StorageKey, attachment.Size)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachment.Id,
+		}).Errorf("Failed to sign upload URL")
+		return nil, err
+	}
+
+	return &AttachmentResponse{
+		Attachment:   toAttachment(attachment),
+		Url:          uploadURL.URL,
+		UrlMethod:    uploadURL.Method,
+		UrlFields:    uploadURL.Fields,
+		UrlExpiresAt: uploadURL.ExpiresAt.UnixMilli(),
+	}, nil
+}
+
+// CompleteAttachment verifies the uploaded content against the declared size and type and records
+// its checksum and dimensions, completing an uploaded attachment return it as is. The content is moved
+// to a key no upload URL was signed for first, so that it cannot be replaced once verified
+func (h *Handler) CompleteAttachment(ctx context.Context, request *AttachmentRequest) (*AttachmentResponse, error) {
+	uploader, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := h.uploaderAttachment(ctx, request.AttachmentId, uploader)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.IsUploaded() {
+		return &AttachmentResponse{Attachment: toAttachment(attachment)}, nil
+	}
+
+	uploadKey, sealedKey := attachment.StorageKey, uuid.NewString()
+	if err := h.blobs.Move(ctx, uploadKey, sealedKey); err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "attachment %d is not uploaded", attachment.Id)
+		}
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachment.Id,
+		}).Errorf("Failed to seal attachment")
+		return nil, err
+	}
+	attachment.StorageKey = sealedKey
+
+	// the content is read outside of the transaction, it can be large
+	inspection, err := h.inspect(ctx, attachment)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachment.Id,
+		}).Errorf("Failed to verify attachment")
+		h.unseal(ctx, attachment.Id, sealedKey, uploadKey)
+		return nil, err
+	}
+
+	attachment.Checksum = inspection.Checksum
+	attachment.Width, attachment.Height = inspection.Width, inspection.Height
+	attachment.Status = models.AttachmentStatusUploaded
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		return store.Attachments().Update(ctx, &attachment)
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachment.Id,
+		}).Errorf("Failed to complete attachment")
+		h.unseal(ctx, attachment.Id, sealedKey, uploadKey)
+		return nil, err
+	}
+
+	return &AttachmentResponse{Attachment: toAttachment(attachment)}, nil
+}
+
+// unseal moves the content back to the upload key of a pending attachment, so that it can be uploaded
+// again and completed once more
+func (h *Handler) unseal(ctx context.Context, attachmentId int, sealedKey string, uploadKey string) {
+	if err := h.blobs.Move(ctx, sealedKey, uploadKey); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachmentId,
+		}).Errorf("Failed to unseal attachment")
+	}
+}
+
+// GetAttachment return an uploaded attachment with a download URL, to its uploader and to the members
+// of the conversation it was sent in
+func (h *Handler) GetAttachment(ctx context.Context, request *AttachmentRequest) (*AttachmentResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment models.Attachment
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		attachment, err = findAttachment(ctx, store, request.AttachmentId)
+		if err != nil || attachment.Uploader == requester {
+			return err
+		}
+		if attachment.MessageId == nil {
+			return status.Errorf(codes.NotFound, "attachment %d not found", request.AttachmentId)
+		}
+
+		message, err := store.Messages().Get(ctx, repository.MessageFilter{Id: attachment.MessageId})
+		if err != nil {
+			return err
+		}
+		_, err = h.authorizer.Authorize(ctx, store, requester, message.ConversationId, authorization.ActionRead)
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": request.AttachmentId,
+		}).Errorf("Failed to get attachment")
+		return nil, err
+	}
+	if !attachment.IsUploaded() {
+		return nil, status.Errorf(codes.FailedPrecondition, "attachment %d is not uploaded", attachment.Id)
+	}
+
+	downloadURL, err := h.blobs.DownloadURL(ctx, attachment.StorageKey)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":         err,
+			"attachment_id": attachment.Id,
+		}).Errorf("Failed to sign download URL")
+		return nil, err
+	}
+
+	return &AttachmentResponse{
+		Attachment:   toAttachment(attachment),
+		Url:          downloadURL.URL,
+		UrlExpiresAt: downloadURL.ExpiresAt.UnixMilli(),
+	}, nil
+}
+
+func (h *Handler) uploaderAttachment(ctx context.Context, attachmentId int, uploader string) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		attachment, err = findAttachment(ctx, store, attachmentId)
+		if err == nil && attachment.Uploader != uploader {
+			return status.Errorf(codes.PermissionDenied, "attachment %d belongs to another user", attachmentId)
+		}
+		return err
+	})
+	return attachment, err
+}
+
+// inspect reads the uploaded content, images must be of the declared type for their dimensions to be trusted
+func (h *Handler) inspect(ctx context.Context, attachment models.Attachment) (blobstore.Inspection, error) {
+	content, err := h.blobs.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return blobstore.Inspection{}, status.Errorf(codes.FailedPrecondition, "attachment %d is not uploaded", attachment.Id)
+	}
+	if err != nil {
+		return blobstore.Inspection{}, err
+	}
+	defer content.Close()
+
+	// a byte past the declared size is enough to reject the content
+	inspection, err := blobstore.Inspect(io.LimitReader(content, attachment.Size+1))
+	if err != nil {
+		return inspection, err
+	}
+
+	if inspection.Size > attachment.Size {
+		return inspection, status.Errorf(codes.FailedPrecondition, "uploaded more than the %d bytes declared", attachment.Size)
+	}
+	if inspection.Size != attachment.Size {
+		return inspection, status.Errorf(codes.FailedPrecondition, "uploaded %d bytes, %d were declared", inspection.Size, attachment.Size)
+	}
+	declared, _, _ := mime.ParseMediaType(attachment.MimeType)
+	if strings.HasPrefix(declared, "image/") && inspection.MimeType != declared {
+		return inspection, status.Errorf(codes.FailedPrecondition, "uploaded content is %s, %s was declared", inspection.MimeType, declared)
+	}
+	return inspection, nil
+}
+
+func findAttachment(ctx context.Context, store uow.IStore, attachmentId int) (models.Attachment, error) {
+	attachment, err := store.Attachments().Get(ctx, repository.AttachmentFilter{Id: &attachmentId})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return attachment, status.Errorf(codes.NotFound, "attachment %d not found", attachmentId)
+	}
+	return attachment, err
+}
+
+// attachmentIdsFromContext return the attachments sent with a message, without duplicates
+func attachmentIdsFromContext(ctx context.Context, limit int) ([]int, error) {
+	values := metadata.ValueFromIncomingContext(ctx, constants.AttachmentIdsMetadataKey)
+	if len(values) == constants.Zero || values[0] == "" {
+		return nil, nil
+	}
+
+	ids := make([]int, 0)
+	for _, value := range strings.Split(values[0], ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || id <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "%s must be a comma separated list of attachment ids", constants.AttachmentIdsMetadataKey)
+		}
+		ids = append(ids, id)
+	}
+
+	ids = lo.Uniq(ids)
+	if len(ids) > limit {
+		return nil, status.Errorf(codes.InvalidArgument, "a message holds at most %d attachments", limit)
+	}
+	return ids, nil
+}
+
+// attachmentsOf return the attachments of the messages keyed by message id, messages without attachments are left out
+func attachmentsOf(ctx context.Context, store uow.IStore, messageIds []int) (map[string][]Attachment, error) {
+	if len(messageIds) == 0 {
+		return nil, nil
+	}
+
+	records, err := store.Attachments().List(ctx, repository.AttachmentFilter{MessageIds: messageIds})
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make(map[string][]Attachment)
+	for _, record := range records {
+		messageId := strconv.Itoa(*record.MessageId)
+		attachments[messageId] = append(attachments[messageId], toAttachment(record))
+	}
+	return attachments, nil
+}
+
+func toAttachment(attachment models.Attachment) Attachment {
+	result := Attachment{
+		AttachmentId: strconv.Itoa(attachment.Id),
+		FileName:     attachment.FileName,
+		MimeType:     attachment.MimeType,
+		Size:         attachment.Size,
+		Checksum:     attachment.Checksum,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		Status:       attachment.Status,
+	}
+	if attachment.MessageId != nil {
+		result.MessageId = strconv.Itoa(*attachment.MessageId)
+	}
+	return result
+}
+
+func parseAttachmentId(pathParams map[string]string) (int, error) {
+	attachmentId, err := strconv.Atoi(pathParams["attachment_id"])
+	if err != nil || attachmentId <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid attachment_id")
+	}
+	return attachmentId, nil
+}
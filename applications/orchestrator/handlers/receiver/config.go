@@ -0,0 +1,21 @@
+package receiver
+
+const (
+	defaultMaxAttachmentSize        = 25 << 20
+	defaultMaxAttachmentsPerMessage = 10
+)
+
+// Config hold receiver config
+type Config struct {
+	// MaxAttachmentSize bounds the declared and uploaded size of an attachment in bytes
+	MaxAttachmentSize        int64 `json:"max_attachment_size" mapstructure:"max_attachment_size" yaml:"max_attachment_size"`
+	MaxAttachmentsPerMessage int   `json:"max_attachments_per_message" mapstructure:"max_attachments_per_message" yaml:"max_attachments_per_message"`
+}
+
+// DefaultConfig return a default receiver config
+func DefaultConfig() Config {
+	return Config{
+		MaxAttachmentSize:        defaultMaxAttachmentSize,
+		MaxAttachmentsPerMessage: defaultMaxAttachmentsPerMessage,
+	}
+}
@@ -0,0 +1,357 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+type Member struct {
+	UserIdentification string `json:"user_identification"`
+	Role               string `json:"role"`
+}
+
+type ConversationResponse struct {
+	ConversationId int64    `json:"conversation_id"`
+	Members        []Member `json:"members"`
+}
+
+type CreateConversationRequest struct {
+	Creator    string   `json:"creator"`
+	Members    []string `json:"members"`
+	PostPolicy string   `json:"post_policy"`
+}
+
+func (r *CreateConversationRequest) Validate() error {
+	if lo.Contains(r.Members, "") {
+		return errors.New("members must not be empty")
+	}
+	if r.PostPolicy != "" && !models.IsValidPostPolicy(r.PostPolicy) {
+		return errors.New("post_policy must be everyone or admins")
+	}
+	return nil
+}
+
+type AddMembersRequest struct {
+	ConversationId int64    `json:"conversation_id"`
+	Requester      string   `json:"requester"`
+	Members        []string `json:"members"`
+	Role           string   `json:"role"`
+}
+
+func (r *AddMembersRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if len(r.Members) == 0 || lo.Contains(r.Members, "") {
+		return errors.New("members must be a non empty list of user identifications")
+	}
+	if r.Role != "" && (!models.IsValidRole(r.Role) || r.Role == models.RoleOwner) {
+		return errors.New("role must be one of admin, member or reader")
+	}
+	return nil
+}
+
+type RemoveMemberRequest struct {
+	ConversationId     int64  `json:"conversation_id"`
+	Requester          string `json:"requester"`
+	UserIdentification string `json:"user_identification"`
+}
+
+func (r *RemoveMemberRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if r.UserIdentification == "" {
+		return errors.New("user_identification is required")
+	}
+	return nil
+}
+
+type UpdateMemberRoleRequest struct {
+	ConversationId     int64  `json:"conversation_id"`
+	Requester          string `json:"requester"`
+	UserIdentification string `json:"user_identification"`
+	Role               string `json:"role"`
+}
+
+func (r *UpdateMemberRoleRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if r.UserIdentification == "" {
+		return errors.New("user_identification is required")
+	}
+	if !models.IsValidRole(r.Role) {
+		return errors.New("role must be one of owner, admin, member or reader")
+	}
+	return nil
+}
+
+func decodeCreateConversationRequest(r *http.Request, _ map[string]string) (interface{}, error) {
+	request := &CreateConversationRequest{}
+	return request, server.DecodeJSON(r, request)
+}
+
+func decodeAddMembersRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &AddMembersRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	request.ConversationId, err = parseConversationId(pathParams)
+	return request, err
+}
+
+func decodeRemoveMemberRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoveMemberRequest{
+		ConversationId:     conversationId,
+		Requester:          r.URL.Query().Get("requester"),
+		UserIdentification: pathParams["user_identification"],
+	}, nil
+}
+
+func decodeUpdateMemberRoleRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &UpdateMemberRoleRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	request.ConversationId, err = parseConversationId(pathParams)
+	request.UserIdentification = pathParams["user_identification"]
+	return request, err
+}
+
+func (h *Handler) CreateConversation(ctx context.Context, request *CreateConversationRequest) (*ConversationResponse, error) {
+	creator, err := interceptor.Identify(ctx, request.Creator)
+	if err != nil {
+		return nil, err
+	}
+	userIdentifications := lo.Uniq(append([]string{creator}, request.Members...))
+
+	var response *ConversationResponse
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if err := ensureUsers(ctx, store, userIdentifications); err != nil {
+			return err
+		}
+
+		postPolicy := request.PostPolicy
+		if postPolicy == "" {
+			postPolicy = models.PostPolicyEveryone
+		}
+		conversation, err := store.Conversations().Save(ctx, &models.Conversation{PostPolicy: postPolicy})
+		if err != nil {
+			return err
+		}
+
+		userConversations := lo.Map(userIdentifications, func(item string, _ int) models.UserConversation {
+			role := models.RoleMember
+			if item == creator {
+				role = models.RoleOwner
+			}
+			return models.UserConversation{
+				UserIdentification: item,
+				ConversationId:     conversation.Id,
+				Role:               role,
+			}
+		})
+		if _, err := store.UserConversations().SaveMany(ctx, userConversations); err != nil {
+			return err
+		}
+
+		response = toConversationResponse(conversation.Id, userConversations)
+		return nil
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":   err,
+			"creator": creator,
+		}).Errorf("Failed to create conversation")
+		return nil, err
+	}
+
+	logger.WithFields(logger.Fields{
+		"conversation_id": response.ConversationId,
+		"creator":         creator,
+	}).Infof("Conversation created")
+	return response, nil
+}
+
+func (h *Handler) AddMembers(ctx context.Context, request *AddMembersRequest) (*ConversationResponse, error) {
+	requesterId, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+	role := request.Role
+	if role == "" {
+		role = models.RoleMember
+	}
+
+	return h.updateMembers(ctx, request.ConversationId, func(store uow.IStore) error {
+		requester, err := h.authorizer.Authorize(ctx, store, requesterId, request.ConversationId, authorization.ActionManageMembers)
+		if err != nil {
+			return err
+		}
+		if !models.Outranks(requester.Role, role) {
+			return status.Errorf(codes.PermissionDenied, "a %s cannot add a %s", requester.Role, role)
+		}
+
+		if err := ensureUsers(ctx, store, request.Members); err != nil {
+			return err
+		}
+
+		userConversations := lo.Map(lo.Uniq(request.Members), func(item string, _ int) models.UserConversation {
+			return models.UserConversation{
+				UserIdentification: item,
+				ConversationId:     request.ConversationId,
+				Role:               role,
+			}
+		})
+		_, err = store.UserConversations().SaveManyIgnoreConflicts(ctx, userConversations)
+		return err
+	})
+}
+
+func (h *Handler) RemoveMember(ctx context.Context, request *RemoveMemberRequest) (*ConversationResponse, error) {
+	requesterId, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.updateMembers(ctx, request.ConversationId, func(store uow.IStore) error {
+		action := authorization.ActionManageMembers
+		if requesterId == request.UserIdentification {
+			action = authorization.ActionRead
+		}
+		requester, err := h.authorizer.Authorize(ctx, store, requesterId, request.ConversationId, action)
+		if err != nil {
+			return err
+		}
+		target, err := member(ctx, store, request.ConversationId, request.UserIdentification)
+		if err != nil {
+			return err
+		}
+
+		if target.UserIdentification == requester.UserIdentification {
+			if target.Role == models.RoleOwner {
+				return status.Error(codes.FailedPrecondition, "the owner must transfer ownership before leaving")
+			}
+		} else if !models.Outranks(requester.Role, target.Role) {
+			return status.Errorf(codes.PermissionDenied, "a %s cannot remove a %s", requester.Role, target.Role)
+		}
+
+		return store.UserConversations().Delete(ctx, repository.UserConversationFilter{
+			ConversationId:     &request.ConversationId,
+			UserIdentification: &request.UserIdentification,
+		})
+	})
+}
+
+// UpdateMemberRole changes the role of a member, giving the owner role transfers the ownership
+// and leaves the previous owner as admin
+func (h *Handler) UpdateMemberRole(ctx context.Context, request *UpdateMemberRoleRequest) (*ConversationResponse, error) {
+	requesterId, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.updateMembers(ctx, request.ConversationId, func(store uow.IStore) error {
+		requester, err := h.authorizer.Authorize(ctx, store, requesterId, request.ConversationId, authorization.ActionManageMembers)
+		if err != nil {
+			return err
+		}
+		target, err := member(ctx, store, request.ConversationId, request.UserIdentification)
+		if err != nil {
+			return err
+		}
+
+		if request.Role == models.RoleOwner {
+			if requester.Role != models.RoleOwner {
+				return status.Error(codes.PermissionDenied, "only the owner can transfer the ownership")
+			}
+			if err := store.UserConversations().UpdateRole(ctx, requester.Id, models.RoleAdmin); err != nil {
+				return err
+			}
+		} else if !models.Outranks(requester.Role, target.Role) || !models.Outranks(requester.Role, request.Role) {
+			return status.Errorf(codes.PermissionDenied, "a %s cannot make a %s %s", requester.Role, target.Role, request.Role)
+		}
+
+		return store.UserConversations().UpdateRole(ctx, target.Id, request.Role)
+	})
+}
+
+// updateMembers runs the block in a transaction and return the resulting members of the conversation
+func (h *Handler) updateMembers(ctx context.Context, conversationId int64, block uow.Block) (*ConversationResponse, error) {
+	var response *ConversationResponse
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if err := block(store); err != nil {
+			return err
+		}
+
+		userConversations, err := store.UserConversations().List(ctx, repository.UserConversationFilter{
+			ConversationId: &conversationId,
+		})
+		if err != nil {
+			return err
+		}
+		response = toConversationResponse(conversationId, userConversations)
+		return nil
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": conversationId,
+		}).Errorf("Failed to update conversation members")
+		return nil, err
+	}
+	return response, nil
+}
+
+func member(ctx context.Context, store uow.IStore, conversationId int64, userIdentification string) (models.UserConversation, error) {
+	userConversation, err := store.UserConversations().Get(ctx, repository.UserConversationFilter{
+		ConversationId:     &conversationId,
+		UserIdentification: &userIdentification,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return userConversation, status.Errorf(codes.NotFound, "%s is not a member of conversation %d", userIdentification, conversationId)
+	}
+	return userConversation, err
+}
+
+// ensureUsers creates the missing users referenced by user_conversations
+func ensureUsers(ctx context.Context, store uow.IStore, userIdentifications []string) error {
+	users := lo.Map(lo.Uniq(userIdentifications), func(item string, _ int) models.User {
+		return models.User{Identification: item}
+	})
+	_, err := store.Users().SaveManyIgnoreConflicts(ctx, users)
+	return err
+}
+
+func toConversationResponse(conversationId int64, userConversations []models.UserConversation) *ConversationResponse {
+	return &ConversationResponse{
+		ConversationId: conversationId,
+		Members: lo.Map(userConversations, func(item models.UserConversation, _ int) Member {
+			return Member{
+				UserIdentification: item.UserIdentification,
+				Role:               item.Role,
+			}
+		}),
+	}
+}
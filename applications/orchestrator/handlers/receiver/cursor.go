@@ -0,0 +1,51 @@
+package receiver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+)
+
+// encodeCursor return the opaque cursor of a message, made of its creation time in milliseconds and its id
+func encodeCursor(message models.Message) string {
+	raw := fmt.Sprintf("%d:%d", message.CreatedAt.UnixMilli(), message.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*repository.MessageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "malformed cursor")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, status.Error(codes.InvalidArgument, "malformed cursor")
+	}
+
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "malformed cursor")
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "malformed cursor")
+	}
+
+	return &repository.MessageCursor{
+		CreatedAt: time.UnixMilli(millis).UTC(),
+		Id:        id,
+	}, nil
+}
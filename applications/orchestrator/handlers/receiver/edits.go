@@ -0,0 +1,303 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// EditMessageRequest may clear the content, the type of the message decides whether it can be empty
+type EditMessageRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	MessageId      int    `json:"message_id"`
+	Editor         string `json:"editor"`
+	Content        string `json:"content"`
+}
+
+func (r *EditMessageRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	return nil
+}
+
+type DeleteMessageRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	MessageId      int    `json:"message_id"`
+	Requester      string `json:"requester"`
+}
+
+func (r *DeleteMessageRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	return nil
+}
+
+type ListRevisionsRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	MessageId      int    `json:"message_id"`
+	Requester      string `json:"requester"`
+}
+
+func (r *ListRevisionsRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	return nil
+}
+
+// Revision is a previous content of a message, EditedAt is when it was replaced in milliseconds
+type Revision struct {
+	Content  string `json:"content"`
+	EditedBy string `json:"edited_by"`
+	EditedAt int64  `json:"edited_at"`
+}
+
+type ListRevisionsResponse struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+func decodeEditMessageRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &EditMessageRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if request.ConversationId, err = parseConversationId(pathParams); err != nil {
+		return nil, err
+	}
+	request.MessageId, err = parseMessageId(pathParams)
+	return request, err
+}
+
+func decodeDeleteMessageRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+	messageId, err := parseMessageId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeleteMessageRequest{
+		ConversationId: conversationId,
+		MessageId:      messageId,
+		Requester:      r.URL.Query().Get("requester"),
+	}, nil
+}
+
+func decodeListRevisionsRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+	messageId, err := parseMessageId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListRevisionsRequest{
+		ConversationId: conversationId,
+		MessageId:      messageId,
+		Requester:      r.URL.Query().Get("requester"),
+	}, nil
+}
+
+// EditMessage replaces the content of a message, the new content is checked against the type and attachments
+// of the message. The previous content is kept as a revision and the edited message is published to the members
+func (h *Handler) EditMessage(ctx context.Context, request *EditMessageRequest) (*api.Message, error) {
+	editor, err := interceptor.Identify(ctx, request.Editor)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := h.changeMessage(ctx, request.ConversationId, request.MessageId, editor, func(store uow.IStore, message *models.Message) error {
+		if message.IsDeleted() {
+			return status.Errorf(codes.FailedPrecondition, "message %d is deleted", message.Id)
+		}
+		attachments, err := store.Attachments().List(ctx, repository.AttachmentFilter{MessageIds: []int{message.Id}})
+		if err != nil {
+			return err
+		}
+		messageType, err := messagetype.Parse(message.Type)
+		if err != nil {
+			return status.Errorf(codes.FailedPrecondition, "message %d cannot be edited: %s", message.Id, err)
+		}
+		content, err := h.contents.Normalize(messageType, messagetype.Input{Content: request.Content, Attachments: attachments})
+		if err != nil {
+			return err
+		}
+		if message.Content == content.Content && lo.FromPtr(message.Payload) == lo.FromPtr(content.Payload) {
+			return nil
+		}
+
+		if _, err := store.MessageRevisions().Save(ctx, &models.MessageRevision{
+			MessageId: message.Id,
+			Content:   message.Body(),
+			EditedBy:  editor,
+		}); err != nil {
+			return err
+		}
+
+		editedAt := time.Now().UTC().Truncate(time.Millisecond)
+		edited, err := store.Messages().Edit(ctx, message.Id, content.Content, content.Payload, editedAt)
+		if err != nil {
+			return err
+		}
+		if !edited {
+			return status.Errorf(codes.FailedPrecondition, "message %d is deleted", message.Id)
+		}
+
+		message.Content, message.Payload, message.EditedAt = content.Content, content.Payload, &editedAt
+		return events.Enqueue(ctx, store, message.ConversationId, events.KindEdit, converter.ToApiMessage(*message))
+	})
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      request.MessageId,
+		}).Errorf("Failed to edit message")
+		return nil, err
+	}
+
+	return converter.ToApiMessage(message), nil
+}
+
+// DeleteMessage tombstones a message and publishes it without its content, a deleted reply is no longer
+// counted by its root. Deleting a deleted message return it as is
+func (h *Handler) DeleteMessage(ctx context.Context, request *DeleteMessageRequest) (*api.Message, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := h.changeMessage(ctx, request.ConversationId, request.MessageId, requester, func(store uow.IStore, message *models.Message) error {
+		deletedAt := time.Now().UTC().Truncate(time.Millisecond)
+		deleted, err := store.Messages().Tombstone(ctx, message.Id, deletedAt)
+		if err != nil || !deleted {
+			return err
+		}
+		if message.ParentMessageId != nil {
+			if err := store.Messages().RemoveReply(ctx, *message.ParentMessageId); err != nil {
+				return err
+			}
+		}
+
+		message.DeletedAt = &deletedAt
+		return events.Enqueue(ctx, store, message.ConversationId, events.KindDelete, converter.ToApiMessage(*message))
+	})
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      request.MessageId,
+		}).Errorf("Failed to delete message")
+		return nil, err
+	}
+
+	return converter.ToApiMessage(message), nil
+}
+
+// ListRevisions return the previous contents of a message oldest first, they are hidden once the
+// message is deleted
+func (h *Handler) ListRevisions(ctx context.Context, request *ListRevisionsRequest) (*ListRevisionsResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.MessageRevision, 0)
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, requester, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		message, err := conversationMessage(ctx, store, request.ConversationId, request.MessageId)
+		if err != nil || message.IsDeleted() {
+			return err
+		}
+
+		revisions, err = store.MessageRevisions().List(ctx, repository.MessageRevisionFilter{MessageId: &message.Id})
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      request.MessageId,
+		}).Errorf("Failed to list message revisions")
+		return nil, err
+	}
+
+	return &ListRevisionsResponse{
+		Revisions: lo.Map(revisions, func(item models.MessageRevision, _ int) Revision {
+			return Revision{
+				Content:  item.Content,
+				EditedBy: item.EditedBy,
+				EditedAt: item.CreatedAt.UnixMilli(),
+			}
+		}),
+	}, nil
+}
+
+// changeMessage runs the change in a transaction once the requester is known to be the sender of the message
+// or an admin of the conversation, and return the message as changed. The message is locked so that concurrent
+// changes each see the content the previous one left
+func (h *Handler) changeMessage(ctx context.Context, conversationId int64, messageId int, requester string, change func(store uow.IStore, message *models.Message) error) (models.Message, error) {
+	var message models.Message
+	err := h.worker.Do(ctx, func(store uow.IStore) error {
+		membership, err := h.authorizer.Authorize(ctx, store, requester, conversationId, authorization.ActionRead)
+		if err != nil {
+			return err
+		}
+
+		message, err = findConversationMessage(ctx, store, repository.MessageFilter{
+			Id:             &messageId,
+			ConversationId: &conversationId,
+			ForUpdate:      true,
+		})
+		if err != nil {
+			return err
+		}
+		if message.Sender != requester && !models.AtLeast(membership.Role, models.RoleAdmin) {
+			return status.Errorf(codes.PermissionDenied, "only the sender or an admin can change message %d", messageId)
+		}
+
+		return change(store, &message)
+	})
+	return message, err
+}
+
+func conversationMessage(ctx context.Context, store uow.IStore, conversationId int64, messageId int) (models.Message, error) {
+	return findConversationMessage(ctx, store, repository.MessageFilter{
+		Id:             &messageId,
+		ConversationId: &conversationId,
+	})
+}
+
+func findConversationMessage(ctx context.Context, store uow.IStore, filter repository.MessageFilter) (models.Message, error) {
+	message, err := store.Messages().Get(ctx, filter)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return message, status.Errorf(codes.NotFound, "message %d not found in conversation %d", *filter.Id, *filter.ConversationId)
+	}
+	return message, err
+}
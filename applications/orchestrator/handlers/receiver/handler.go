@@ -2,33 +2,52 @@ package receiver
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
 	"github.com/YumikoKawaii/shared/logger"
-	"github.com/YumikoKawaii/shared/pubsub"
-	"github.com/golang/protobuf/proto"
-	"github.com/samber/lo"
-	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/blobstore"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/search"
 )
 
 type Handler struct {
 	api.ReceiverServer
-	connRegistry     connection_registry.Registry
-	messagePublisher pubsub.Publisher
-	worker           uow.IWorker
+	cfg        Config
+	worker     uow.IWorker
+	authorizer authorization.Authorizer
+	blobs      blobstore.BlobStore
+	contents   messagetype.Registry
+	index      search.SearchIndex
 }
 
-func NewHandler(registry connection_registry.Registry, publisher pubsub.Publisher, worker uow.IWorker) *Handler {
+// NewHandler return the receiver handler, messages are fanned out by the outbox relay once committed
+// and attachment contents are kept in the blob store. Message contents are checked by the handler of their type
+// and searched through the index
+func NewHandler(cfg Config, worker uow.IWorker, authorizer authorization.Authorizer, blobs blobstore.BlobStore, contents messagetype.Registry, index search.SearchIndex) *Handler {
 	return &Handler{
-		connRegistry:     registry,
-		messagePublisher: publisher,
-		worker:           worker,
+		cfg:        cfg,
+		worker:     worker,
+		authorizer: authorizer,
+		blobs:      blobs,
+		contents:   contents,
+		index:      index,
 	}
 }
 
@@ -36,90 +55,158 @@ func (h *Handler) SendMessage(ctx context.Context, request *api.SendMessageReque
 	logger.WithFields(logger.Fields{
 		"sender":          request.Sender,
 		"conversation_id": request.ConversationId,
-		"message_type":    request.Type.String(),
+		"message_type":    messagetype.Name(request.Type),
 	}).Infof("SendMessage request received")
 
-	if err := h.worker.Do(ctx, func(store uow.IStore) error {
-		if _, err := store.Messages().Upsert(ctx, &models.Message{
-			Sender:         request.Sender,
-			ConversationId: request.ConversationId,
-			Content:        request.Content,
-			Type:           request.Type.String(),
-		}); err != nil {
-			logger.WithFields(logger.Fields{
-				"error":           err,
-				"conversation_id": request.ConversationId,
-			}).Errorf("Failed to upsert message")
+	sender, err := interceptor.Identify(ctx, request.Sender)
+	if err != nil {
+		return nil, err
+	}
+	// the message is stored and published under the verified sender
+	request.Sender = sender
+
+	clientMessageId, err := clientMessageIdFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := referencesFromContext(ctx, h.cfg.MaxAttachmentsPerMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := h.saveMessage(ctx, request, clientMessageId, refs)
+	if err != nil && clientMessageId != nil && repository.IsDuplicateKey(err) {
+		// a concurrent retry stored the message first
+		message, err = h.findMessage(ctx, request, *clientMessageId)
+	}
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+		}).Errorf("SendMessage failed")
+		return nil, err
+	}
+
+	logger.WithFields(logger.Fields{
+		"conversation_id": request.ConversationId,
+		"message_id":      message.Id,
+	}).Infof("SendMessage completed successfully")
+
+	return &api.SendMessageResponse{
+		Code:    int32(http.StatusOK),
+		Message: "Success",
+		Data: &api.MessageData{
+			MessageId: strconv.Itoa(message.Id),
+			Timestamp: message.CreatedAt.UnixMilli(),
+			Status:    api.MessageStatus_SENT,
+		},
+	}, nil
+}
+
+// saveMessage stores the message with its outbox event, a message already stored under the
+// client message id is returned as is and is not published again. The content is normalized by the handler
+// of its type before anything is written, a thread reply is counted on its root and the attachments are linked
+// to the message
+func (h *Handler) saveMessage(ctx context.Context, request *api.SendMessageRequest, clientMessageId *string, refs references) (models.Message, error) {
+	var message models.Message
+	err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, request.Sender, request.ConversationId, authorization.ActionPost); err != nil {
 			return err
 		}
 
-		userConversations, err := store.UserConversations().List(ctx, repository.UserConversationFilter{
-			ConversationId: &request.ConversationId,
-		})
-		if err != nil {
-			logger.WithFields(logger.Fields{
-				"error":           err,
-				"conversation_id": request.ConversationId,
-			}).Errorf("Failed to list user conversations")
-			return err
+		if clientMessageId != nil {
+			existing, err := store.Messages().Get(ctx, repository.MessageFilter{
+				Sender:          &request.Sender,
+				ConversationId:  &request.ConversationId,
+				ClientMessageId: clientMessageId,
+			})
+			if err == nil {
+				logger.WithFields(logger.Fields{
+					"conversation_id":   request.ConversationId,
+					"client_message_id": *clientMessageId,
+				}).Infof("Message already stored, skipping")
+				message = existing
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
 		}
 
-		userIdentifications := make([]string, constants.Zero)
-		lo.ForEach(userConversations, func(item models.UserConversation, _ int) {
-			userIdentifications = append(userIdentifications, item.UserIdentification)
-		})
+		if err := refs.resolve(ctx, store, request.ConversationId); err != nil {
+			return err
+		}
 
-		servers, err := h.connRegistry.GetServers(ctx, userIdentifications)
+		attachments, err := refs.attachments(ctx, store)
+		if err != nil {
+			return err
+		}
+		content, err := h.contents.Normalize(request.Type, messagetype.Input{Content: request.Content, Attachments: attachments})
 		if err != nil {
-			logger.WithFields(logger.Fields{
-				"error":           err,
-				"conversation_id": request.ConversationId,
-			}).Errorf("Failed to get connected servers")
 			return err
 		}
 
-		messageBytes, err := proto.Marshal(&api.Message{
-			Sender:         request.Sender,
-			ConversationId: request.ConversationId,
-			Content:        request.Content,
-			Type:           request.Type,
-			Timestamp:      time.Now().Unix(),
+		message, err = store.Messages().Save(ctx, &models.Message{
+			Sender:          request.Sender,
+			ConversationId:  request.ConversationId,
+			Content:         content.Content,
+			Payload:         content.Payload,
+			Type:            messagetype.Name(request.Type),
+			ClientMessageId: clientMessageId,
+			ParentMessageId: refs.parentMessageId,
+			QuotedMessageId: refs.quotedMessageId,
+			// truncated so the returned time is the one stored in the TIMESTAMP(3) column
+			CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
 		})
 		if err != nil {
 			logger.WithFields(logger.Fields{
 				"error":           err,
 				"conversation_id": request.ConversationId,
-			}).Errorf("Failed to marshal message")
+			}).Errorf("Failed to save message")
 			return err
 		}
 
-		for _, sv := range servers {
-			topic := constants.GenerateMessagesTopic(sv)
-			if err := h.messagePublisher.Publish(ctx, topic, messageBytes); err != nil {
-				logger.WithFields(logger.Fields{
-					"error":           err,
-					"server":          sv,
-					"conversation_id": request.ConversationId,
-				}).Errorf("Failed to publish message")
+		if err := refs.attach(ctx, store, message); err != nil {
+			return err
+		}
+
+		if message.ParentMessageId != nil {
+			if err := store.Messages().AddReply(ctx, *message.ParentMessageId, message.CreatedAt); err != nil {
 				return err
 			}
 		}
 
-		return nil
-	}); err != nil {
-		logger.WithFields(logger.Fields{
-			"error":           err,
-			"conversation_id": request.ConversationId,
-		}).Errorf("SendMessage failed")
-		return nil, err
+		return events.Enqueue(ctx, store, request.ConversationId, events.KindMessage, converter.ToApiMessage(message))
+	})
+	return message, err
+}
+
+func (h *Handler) findMessage(ctx context.Context, request *api.SendMessageRequest, clientMessageId string) (models.Message, error) {
+	var message models.Message
+	err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		message, err = store.Messages().Get(ctx, repository.MessageFilter{
+			Sender:          &request.Sender,
+			ConversationId:  &request.ConversationId,
+			ClientMessageId: &clientMessageId,
+		})
+		return err
+	})
+	return message, err
+}
+
+// clientMessageIdFromContext return the idempotency key of the request, nil when the client sent none
+func clientMessageIdFromContext(ctx context.Context) (*string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, constants.ClientMessageIdMetadataKey)
+	if len(values) == constants.Zero || values[0] == "" {
+		return nil, nil
 	}
 
-	logger.WithFields(logger.Fields{
-		"conversation_id": request.ConversationId,
-	}).Infof("SendMessage completed successfully")
+	id, err := uuid.Parse(values[0])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s must be a UUID", constants.ClientMessageIdMetadataKey)
+	}
 
-	return &api.SendMessageResponse{
-		Code:    int32(http.StatusOK),
-		Message: "Success",
-	}, nil
+	normalized := id.String()
+	return &normalized, nil
 }
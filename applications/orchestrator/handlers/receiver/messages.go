@@ -0,0 +1,178 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"github.com/samber/lo/mutable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type ListMessagesRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	Requester      string `json:"requester"`
+	Before         string `json:"before"`
+	After          string `json:"after"`
+	PageSize       int    `json:"page_size"`
+}
+
+func (r *ListMessagesRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if r.Before != "" && r.After != "" {
+		return errors.New("only one of before and after can be set")
+	}
+	if r.PageSize < 0 || r.PageSize > maxPageSize {
+		return errors.New("page_size must be between 0 and 200")
+	}
+	return nil
+}
+
+// ListMessagesResponse holds a page in chronological order, BeforeCursor pages to older messages
+// and AfterCursor to newer ones. Reactions, threads and attachments are keyed by message id
+type ListMessagesResponse struct {
+	Messages     []*api.Message          `json:"messages"`
+	Reactions    map[string][]Reaction   `json:"reactions,omitempty"`
+	Threads      map[string]Thread       `json:"threads,omitempty"`
+	Attachments  map[string][]Attachment `json:"attachments,omitempty"`
+	BeforeCursor string                  `json:"before_cursor,omitempty"`
+	AfterCursor  string                  `json:"after_cursor,omitempty"`
+	HasMore      bool                    `json:"has_more"`
+}
+
+func decodeListMessagesRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	request := &ListMessagesRequest{
+		ConversationId: conversationId,
+		Requester:      query.Get("requester"),
+		Before:         query.Get("before"),
+		After:          query.Get("after"),
+	}
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		if request.PageSize, err = strconv.Atoi(pageSize); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_size")
+		}
+	}
+	return request, nil
+}
+
+func (h *Handler) ListMessages(ctx context.Context, request *ListMessagesRequest) (*ListMessagesResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, pageSize, err := pageFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *ListMessagesResponse
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, requester, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		var err error
+		response, err = listPage(ctx, store, filter, pageSize, requester)
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+		}).Errorf("Failed to list messages")
+		return nil, err
+	}
+	return response, nil
+}
+
+// pageFilter return the filter of the page selected by the cursors of the request
+func pageFilter(request *ListMessagesRequest) (repository.MessageFilter, int, error) {
+	before, err := decodeCursor(request.Before)
+	if err != nil {
+		return repository.MessageFilter{}, 0, err
+	}
+	after, err := decodeCursor(request.After)
+	if err != nil {
+		return repository.MessageFilter{}, 0, err
+	}
+
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	return repository.MessageFilter{
+		ConversationId: &request.ConversationId,
+		Before:         before,
+		After:          after,
+	}, pageSize, nil
+}
+
+// listPage return a page of the messages selected by the filter with their reactions and threads
+func listPage(ctx context.Context, store uow.IStore, filter repository.MessageFilter, pageSize int, requester string) (*ListMessagesResponse, error) {
+	// the extra record only tells whether there are more messages
+	filter.Limit = pageSize + 1
+	records, err := store.Messages().List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(records) > pageSize
+	if hasMore {
+		records = records[:pageSize]
+	}
+	if filter.After == nil {
+		mutable.Reverse(records)
+	}
+
+	messageIds := lo.Map(records, func(item models.Message, _ int) int {
+		return item.Id
+	})
+	reactions, err := reactionsOf(ctx, store, messageIds, requester)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := attachmentsOf(ctx, store, messageIds)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListMessagesResponse{
+		Messages: lo.Map(records, func(item models.Message, _ int) *api.Message {
+			return converter.ToApiMessage(item)
+		}),
+		Reactions:   reactions,
+		Threads:     threadsOf(records),
+		Attachments: attachments,
+		HasMore:     hasMore,
+	}
+	if len(records) > 0 {
+		response.BeforeCursor = encodeCursor(records[0])
+		response.AfterCursor = encodeCursor(records[len(records)-1])
+	}
+	return response, nil
+}
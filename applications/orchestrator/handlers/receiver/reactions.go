@@ -0,0 +1,197 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+const maxEmojiLength = 64
+
+type ReactionRequest struct {
+	ConversationId     int64  `json:"conversation_id"`
+	MessageId          int    `json:"message_id"`
+	UserIdentification string `json:"user_identification"`
+	Emoji              string `json:"emoji"`
+}
+
+func (r *ReactionRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if r.Emoji == "" || len(r.Emoji) > maxEmojiLength || !utf8.ValidString(r.Emoji) {
+		return errors.New("emoji must be a non empty UTF-8 string of at most 64 bytes")
+	}
+	return nil
+}
+
+// Reaction is the number of members who reacted with an emoji, Reacted tells whether the requester did
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+type MessageReactionsResponse struct {
+	MessageId string     `json:"message_id"`
+	Reactions []Reaction `json:"reactions"`
+}
+
+func decodeAddReactionRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &ReactionRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if request.ConversationId, err = parseConversationId(pathParams); err != nil {
+		return nil, err
+	}
+	request.MessageId, err = parseMessageId(pathParams)
+	return request, err
+}
+
+func decodeRemoveReactionRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+	messageId, err := parseMessageId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	return &ReactionRequest{
+		ConversationId:     conversationId,
+		MessageId:          messageId,
+		UserIdentification: query.Get("user_identification"),
+		Emoji:              query.Get("emoji"),
+	}, nil
+}
+
+// AddReaction reacts to a message for a member, reacting again with the same emoji changes nothing
+func (h *Handler) AddReaction(ctx context.Context, request *ReactionRequest) (*MessageReactionsResponse, error) {
+	return h.changeReaction(ctx, request, true, func(store uow.IStore, reaction models.MessageReaction) (bool, error) {
+		_, err := store.MessageReactions().Save(ctx, &reaction)
+		if repository.IsDuplicateKey(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// RemoveReaction withdraws a reaction of a member, removing a missing reaction changes nothing
+func (h *Handler) RemoveReaction(ctx context.Context, request *ReactionRequest) (*MessageReactionsResponse, error) {
+	return h.changeReaction(ctx, request, false, func(store uow.IStore, reaction models.MessageReaction) (bool, error) {
+		existing, err := store.MessageReactions().Get(ctx, repository.MessageReactionFilter{
+			MessageId:          &reaction.MessageId,
+			UserIdentification: &reaction.UserIdentification,
+			Emoji:              &reaction.Emoji,
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return true, store.MessageReactions().Delete(ctx, repository.MessageReactionFilter{
+			MessageId:          &existing.MessageId,
+			UserIdentification: &existing.UserIdentification,
+			Emoji:              &existing.Emoji,
+		})
+	})
+}
+
+// changeReaction applies the change for a member of the conversation, publishes it when it added or removed
+// the reaction and return the resulting reactions of the message
+func (h *Handler) changeReaction(ctx context.Context, request *ReactionRequest, added bool, change func(store uow.IStore, reaction models.MessageReaction) (bool, error)) (*MessageReactionsResponse, error) {
+	userIdentification, err := interceptor.Identify(ctx, request.UserIdentification)
+	if err != nil {
+		return nil, err
+	}
+
+	var reactions []Reaction
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, userIdentification, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		message, err := conversationMessage(ctx, store, request.ConversationId, request.MessageId)
+		if err != nil {
+			return err
+		}
+		if message.IsDeleted() {
+			return status.Errorf(codes.FailedPrecondition, "message %d is deleted", message.Id)
+		}
+
+		reaction := models.MessageReaction{
+			MessageId:          message.Id,
+			UserIdentification: userIdentification,
+			Emoji:              request.Emoji,
+		}
+		changed, err := change(store, reaction)
+		if err != nil {
+			return err
+		}
+		if changed {
+			frame := converter.ToApiReaction(reaction, message.ConversationId, added, time.Now().UTC())
+			if err := events.Enqueue(ctx, store, message.ConversationId, events.KindReaction, frame); err != nil {
+				return err
+			}
+		}
+
+		byMessage, err := reactionsOf(ctx, store, []int{message.Id}, userIdentification)
+		reactions = byMessage[strconv.Itoa(message.Id)]
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      request.MessageId,
+		}).Errorf("Failed to change reaction")
+		return nil, err
+	}
+
+	return &MessageReactionsResponse{
+		MessageId: strconv.Itoa(request.MessageId),
+		Reactions: lo.Ternary(reactions == nil, make([]Reaction, 0), reactions),
+	}, nil
+}
+
+// reactionsOf return the reactions of the messages keyed by message id, messages without reactions are left out
+func reactionsOf(ctx context.Context, store uow.IStore, messageIds []int, userIdentification string) (map[string][]Reaction, error) {
+	counts, err := store.MessageReactions().Aggregate(ctx, messageIds, userIdentification)
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make(map[string][]Reaction)
+	for _, count := range counts {
+		messageId := strconv.Itoa(count.MessageId)
+		reactions[messageId] = append(reactions[messageId], Reaction{
+			Emoji:   count.Emoji,
+			Count:   count.Count,
+			Reacted: count.Reacted,
+		})
+	}
+	return reactions, nil
+}
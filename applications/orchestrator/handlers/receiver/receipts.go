@@ -0,0 +1,238 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+type MarkReadRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	Reader         string `json:"reader"`
+	MessageId      string `json:"message_id"`
+}
+
+func (r *MarkReadRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if id, err := strconv.Atoi(r.MessageId); err != nil || id <= 0 {
+		return errors.New("message_id must be a positive integer")
+	}
+	return nil
+}
+
+// MemberCursor is the last message delivered to and read by a member, times are in milliseconds,
+// a message is delivered or read when its id is at or below the cursor
+type MemberCursor struct {
+	ConversationId         int64  `json:"conversation_id"`
+	UserIdentification     string `json:"user_identification"`
+	LastDeliveredMessageId string `json:"last_delivered_message_id"`
+	LastDeliveredAt        int64  `json:"last_delivered_at,omitempty"`
+	LastReadMessageId      string `json:"last_read_message_id"`
+	LastReadAt             int64  `json:"last_read_at,omitempty"`
+}
+
+type ListReceiptsRequest struct {
+	ConversationId int64  `json:"conversation_id"`
+	Requester      string `json:"requester"`
+}
+
+func (r *ListReceiptsRequest) Validate() error {
+	if r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	return nil
+}
+
+type ListReceiptsResponse struct {
+	Members []*MemberCursor `json:"members"`
+}
+
+type UnreadCountsRequest struct {
+	UserIdentification string `json:"user_identification"`
+}
+
+type UnreadCount struct {
+	ConversationId    int64  `json:"conversation_id"`
+	LastReadMessageId string `json:"last_read_message_id"`
+	Unread            int64  `json:"unread"`
+}
+
+type UnreadCountsResponse struct {
+	Conversations []UnreadCount `json:"conversations"`
+}
+
+func decodeMarkReadRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request := &MarkReadRequest{}
+	if err := server.DecodeJSON(r, request); err != nil {
+		return nil, err
+	}
+
+	var err error
+	request.ConversationId, err = parseConversationId(pathParams)
+	return request, err
+}
+
+func decodeListReceiptsRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	conversationId, err := parseConversationId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListReceiptsRequest{
+		ConversationId: conversationId,
+		Requester:      r.URL.Query().Get("requester"),
+	}, nil
+}
+
+func decodeUnreadCountsRequest(r *http.Request, _ map[string]string) (interface{}, error) {
+	return &UnreadCountsRequest{
+		UserIdentification: r.URL.Query().Get("user_identification"),
+	}, nil
+}
+
+// MarkRead advances the read cursor of the reader, a cursor already at or past the message is left as is
+// and no receipt is published
+func (h *Handler) MarkRead(ctx context.Context, request *MarkReadRequest) (*MemberCursor, error) {
+	reader, err := interceptor.Identify(ctx, request.Reader)
+	if err != nil {
+		return nil, err
+	}
+	messageId, _ := strconv.Atoi(request.MessageId)
+
+	var userConversation models.UserConversation
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, reader, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		if _, err := store.Messages().Get(ctx, repository.MessageFilter{
+			Id:             &messageId,
+			ConversationId: &request.ConversationId,
+		}); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return status.Errorf(codes.NotFound, "message %d not found in conversation %d", messageId, request.ConversationId)
+			}
+			return err
+		}
+
+		advanced, err := store.UserConversations().AdvanceLastRead(ctx, request.ConversationId, reader, messageId, time.Now().UTC().Truncate(time.Millisecond))
+		if err != nil {
+			return err
+		}
+
+		userConversation, err = member(ctx, store, request.ConversationId, reader)
+		if err != nil || !advanced {
+			return err
+		}
+		return events.Enqueue(ctx, store, request.ConversationId, events.KindReceipt, converter.ToApiReceipt(userConversation, api.MessageStatus_READ))
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      messageId,
+		}).Errorf("Failed to mark conversation as read")
+		return nil, err
+	}
+
+	return toMemberCursor(userConversation), nil
+}
+
+// ListReceipts return the delivery and read cursors of every member so senders can show which of their
+// messages were delivered and read
+func (h *Handler) ListReceipts(ctx context.Context, request *ListReceiptsRequest) (*ListReceiptsResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	userConversations := make([]models.UserConversation, 0)
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, requester, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		var err error
+		userConversations, err = store.UserConversations().List(ctx, repository.UserConversationFilter{
+			ConversationId: &request.ConversationId,
+		})
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+		}).Errorf("Failed to list receipts")
+		return nil, err
+	}
+
+	return &ListReceiptsResponse{
+		Members: lo.Map(userConversations, func(item models.UserConversation, _ int) *MemberCursor {
+			return toMemberCursor(item)
+		}),
+	}, nil
+}
+
+// UnreadCounts return the number of unread messages of every conversation of the user
+func (h *Handler) UnreadCounts(ctx context.Context, request *UnreadCountsRequest) (*UnreadCountsResponse, error) {
+	userIdentification, err := interceptor.Identify(ctx, request.UserIdentification)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]repository.UnreadCount, 0)
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		counts, err = store.UserConversations().UnreadCounts(ctx, userIdentification)
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":               err,
+			"user_identification": userIdentification,
+		}).Errorf("Failed to count unread messages")
+		return nil, err
+	}
+
+	return &UnreadCountsResponse{
+		Conversations: lo.Map(counts, func(item repository.UnreadCount, _ int) UnreadCount {
+			return UnreadCount{
+				ConversationId:    item.ConversationId,
+				LastReadMessageId: strconv.Itoa(item.LastReadMessageId),
+				Unread:            item.Unread,
+			}
+		}),
+	}, nil
+}
+
+func toMemberCursor(userConversation models.UserConversation) *MemberCursor {
+	cursor := &MemberCursor{
+		ConversationId:         userConversation.ConversationId,
+		UserIdentification:     userConversation.UserIdentification,
+		LastDeliveredMessageId: strconv.Itoa(userConversation.LastDeliveredMessageId),
+		LastReadMessageId:      strconv.Itoa(userConversation.LastReadMessageId),
+	}
+	if userConversation.LastDeliveredAt != nil {
+		cursor.LastDeliveredAt = userConversation.LastDeliveredAt.UnixMilli()
+	}
+	if userConversation.LastReadAt != nil {
+		cursor.LastReadAt = userConversation.LastReadAt.UnixMilli()
+	}
+	return cursor
+}
@@ -0,0 +1,158 @@
+package receiver
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// Routes return the receiver methods served on the gateway mux besides the generated ones
+func (h *Handler) Routes() []server.Route {
+	return []server.Route{
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages",
+			FullMethod: "/orchestrator.Receiver/ListMessages",
+			Decode:     decodeListMessagesRequest,
+			Handler:    server.Unary(h.ListMessages),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}/thread",
+			FullMethod: "/orchestrator.Receiver/ListThread",
+			Decode:     decodeListThreadRequest,
+			Handler:    server.Unary(h.ListThread),
+		},
+		{
+			Method:     http.MethodPatch,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}",
+			FullMethod: "/orchestrator.Receiver/EditMessage",
+			Decode:     decodeEditMessageRequest,
+			Handler:    server.Unary(h.EditMessage),
+		},
+		{
+			Method:     http.MethodDelete,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}",
+			FullMethod: "/orchestrator.Receiver/DeleteMessage",
+			Decode:     decodeDeleteMessageRequest,
+			Handler:    server.Unary(h.DeleteMessage),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}/revisions",
+			FullMethod: "/orchestrator.Receiver/ListRevisions",
+			Decode:     decodeListRevisionsRequest,
+			Handler:    server.Unary(h.ListRevisions),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}/reactions",
+			FullMethod: "/orchestrator.Receiver/AddReaction",
+			Decode:     decodeAddReactionRequest,
+			Handler:    server.Unary(h.AddReaction),
+		},
+		{
+			Method:     http.MethodDelete,
+			Pattern:    "/api/v1/conversations/{conversation_id}/messages/{message_id}/reactions",
+			FullMethod: "/orchestrator.Receiver/RemoveReaction",
+			Decode:     decodeRemoveReactionRequest,
+			Handler:    server.Unary(h.RemoveReaction),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/messages/search",
+			FullMethod: "/orchestrator.Receiver/SearchMessages",
+			Decode:     decodeSearchMessagesRequest,
+			Handler:    server.Unary(h.SearchMessages),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/attachments",
+			FullMethod: "/orchestrator.Receiver/CreateAttachment",
+			Decode:     decodeCreateAttachmentRequest,
+			Handler:    server.Unary(h.CreateAttachment),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/attachments/{attachment_id}/complete",
+			FullMethod: "/orchestrator.Receiver/CompleteAttachment",
+			Decode:     decodeCompleteAttachmentRequest,
+			Handler:    server.Unary(h.CompleteAttachment),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/attachments/{attachment_id}",
+			FullMethod: "/orchestrator.Receiver/GetAttachment",
+			Decode:     decodeGetAttachmentRequest,
+			Handler:    server.Unary(h.GetAttachment),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/conversations",
+			FullMethod: "/orchestrator.Receiver/CreateConversation",
+			Decode:     decodeCreateConversationRequest,
+			Handler:    server.Unary(h.CreateConversation),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/conversations/{conversation_id}/members",
+			FullMethod: "/orchestrator.Receiver/AddMembers",
+			Decode:     decodeAddMembersRequest,
+			Handler:    server.Unary(h.AddMembers),
+		},
+		{
+			Method:     http.MethodDelete,
+			Pattern:    "/api/v1/conversations/{conversation_id}/members/{user_identification}",
+			FullMethod: "/orchestrator.Receiver/RemoveMember",
+			Decode:     decodeRemoveMemberRequest,
+			Handler:    server.Unary(h.RemoveMember),
+		},
+		{
+			Method:     http.MethodPatch,
+			Pattern:    "/api/v1/conversations/{conversation_id}/members/{user_identification}",
+			FullMethod: "/orchestrator.Receiver/UpdateMemberRole",
+			Decode:     decodeUpdateMemberRoleRequest,
+			Handler:    server.Unary(h.UpdateMemberRole),
+		},
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/conversations/{conversation_id}/read",
+			FullMethod: "/orchestrator.Receiver/MarkRead",
+			Decode:     decodeMarkReadRequest,
+			Handler:    server.Unary(h.MarkRead),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/conversations/{conversation_id}/receipts",
+			FullMethod: "/orchestrator.Receiver/ListReceipts",
+			Decode:     decodeListReceiptsRequest,
+			Handler:    server.Unary(h.ListReceipts),
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/api/v1/unread",
+			FullMethod: "/orchestrator.Receiver/UnreadCounts",
+			Decode:     decodeUnreadCountsRequest,
+			Handler:    server.Unary(h.UnreadCounts),
+		},
+	}
+}
+
+func parseConversationId(pathParams map[string]string) (int64, error) {
+	conversationId, err := strconv.ParseInt(pathParams["conversation_id"], 10, 64)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid conversation_id")
+	}
+	return conversationId, nil
+}
+
+func parseMessageId(pathParams map[string]string) (int, error) {
+	messageId, err := strconv.Atoi(pathParams["message_id"])
+	if err != nil || messageId <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid message_id")
+	}
+	return messageId, nil
+}
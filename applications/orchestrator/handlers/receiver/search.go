@@ -0,0 +1,217 @@
+package receiver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/search"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	maxQueryLength        = 256
+	snippetLength         = 160
+)
+
+// SearchMessagesRequest searches the conversations of the requester, Since and Until bound the creation
+// time in milliseconds and Until is excluded
+type SearchMessagesRequest struct {
+	Requester      string `json:"requester"`
+	Query          string `json:"query"`
+	ConversationId *int64 `json:"conversation_id"`
+	Sender         string `json:"sender"`
+	Since          int64  `json:"since"`
+	Until          int64  `json:"until"`
+	PageToken      string `json:"page_token"`
+	PageSize       int    `json:"page_size"`
+}
+
+func (r *SearchMessagesRequest) Validate() error {
+	if strings.TrimSpace(r.Query) == "" {
+		return errors.New("query is required")
+	}
+	if len([]rune(r.Query)) > maxQueryLength {
+		return errors.New("query must be at most 256 characters long")
+	}
+	if r.ConversationId != nil && *r.ConversationId <= 0 {
+		return errors.New("conversation_id must be positive")
+	}
+	if r.Since < 0 || r.Until < 0 || (r.Until > 0 && r.Until <= r.Since) {
+		return errors.New("until must be after since")
+	}
+	if r.PageSize < 0 || r.PageSize > maxSearchPageSize {
+		return errors.New("page_size must be between 0 and 100")
+	}
+	return nil
+}
+
+// SearchResult is a matching message with a snippet of its content, Score is only comparable within a search
+type SearchResult struct {
+	Message *api.Message   `json:"message"`
+	Snippet search.Snippet `json:"snippet"`
+	Score   float64        `json:"score"`
+}
+
+// SearchMessagesResponse holds a page of results best first, NextPageToken selects the next one
+type SearchMessagesResponse struct {
+	Results       []SearchResult `json:"results"`
+	NextPageToken string         `json:"next_page_token,omitempty"`
+	HasMore       bool           `json:"has_more"`
+}
+
+func decodeSearchMessagesRequest(r *http.Request, _ map[string]string) (interface{}, error) {
+	query := r.URL.Query()
+	request := &SearchMessagesRequest{
+		Requester: query.Get("requester"),
+		Query:     query.Get("q"),
+		Sender:    query.Get("sender"),
+		PageToken: query.Get("page_token"),
+	}
+
+	if conversationId := query.Get("conversation_id"); conversationId != "" {
+		id, err := strconv.ParseInt(conversationId, 10, 64)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid conversation_id")
+		}
+		request.ConversationId = &id
+	}
+	for name, value := range map[string]*int64{"since": &request.Since, "until": &request.Until} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s", name)
+			}
+			*value = parsed
+		}
+	}
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		var err error
+		if request.PageSize, err = strconv.Atoi(pageSize); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_size")
+		}
+	}
+	return request, nil
+}
+
+// SearchMessages return the messages matching the query in the conversations the requester belongs to,
+// ranked by the search index with the matching terms highlighted
+func (h *Handler) SearchMessages(ctx context.Context, request *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := decodePageToken(request.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = defaultSearchPageSize
+	}
+
+	query := search.Query{
+		UserIdentification: requester,
+		Text:               strings.TrimSpace(request.Query),
+		ConversationId:     request.ConversationId,
+		Offset:             offset,
+		// the extra hit only tells whether there are more results
+		Limit: pageSize + 1,
+	}
+	if request.Sender != "" {
+		query.Sender = &request.Sender
+	}
+	if request.Since > 0 {
+		query.Since = lo.ToPtr(time.UnixMilli(request.Since).UTC())
+	}
+	if request.Until > 0 {
+		query.Until = lo.ToPtr(time.UnixMilli(request.Until).UTC())
+	}
+
+	hits, err := h.index.Search(ctx, query)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":     err,
+			"requester": requester,
+		}).Errorf("Failed to search messages")
+		return nil, err
+	}
+
+	response := &SearchMessagesResponse{Results: make([]SearchResult, 0, len(hits))}
+	if len(hits) > pageSize {
+		hits = hits[:pageSize]
+		response.HasMore = true
+		response.NextPageToken = encodePageToken(offset + pageSize)
+	}
+	if len(hits) == 0 {
+		return response, nil
+	}
+
+	var records []models.Message
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		records, err = store.Messages().List(ctx, repository.MessageFilter{
+			Ids: lo.Map(hits, func(item search.Hit, _ int) int { return item.MessageId }),
+		})
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":     err,
+			"requester": requester,
+		}).Errorf("Failed to load searched messages")
+		return nil, err
+	}
+
+	// the index may lag behind deletions, a message deleted meanwhile is left out
+	byId := lo.KeyBy(records, func(item models.Message) int { return item.Id })
+	terms := search.Terms(query.Text)
+	for _, hit := range hits {
+		message, ok := byId[hit.MessageId]
+		if !ok || message.IsDeleted() {
+			continue
+		}
+		response.Results = append(response.Results, SearchResult{
+			Message: converter.ToApiMessage(message),
+			Snippet: search.Highlight(message.Content, terms, snippetLength),
+			Score:   hit.Score,
+		})
+	}
+	return response, nil
+}
+
+// encodePageToken return the opaque token of the results from offset on
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "malformed page_token")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, status.Error(codes.InvalidArgument, "malformed page_token")
+	}
+	return offset, nil
+}
@@ -0,0 +1,220 @@
+package receiver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+// Thread is how a listed message relates to threads and quotes, times are in milliseconds
+type Thread struct {
+	ParentMessageId string `json:"parent_message_id,omitempty"`
+	QuotedMessageId string `json:"quoted_message_id,omitempty"`
+	ReplyCount      int    `json:"reply_count,omitempty"`
+	LastReplyAt     int64  `json:"last_reply_at,omitempty"`
+}
+
+type ListThreadRequest struct {
+	ListMessagesRequest
+	MessageId int `json:"message_id"`
+}
+
+// ListThreadResponse holds the root of the thread and a page of its replies
+type ListThreadResponse struct {
+	Root *api.Message `json:"root"`
+	*ListMessagesResponse
+}
+
+func decodeListThreadRequest(r *http.Request, pathParams map[string]string) (interface{}, error) {
+	request, err := decodeListMessagesRequest(r, pathParams)
+	if err != nil {
+		return nil, err
+	}
+	messageId, err := parseMessageId(pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListThreadRequest{
+		ListMessagesRequest: *request.(*ListMessagesRequest),
+		MessageId:           messageId,
+	}, nil
+}
+
+// ListThread pages the replies of a thread like ListMessages pages a conversation, the message id may be
+// the root or any reply of the thread
+func (h *Handler) ListThread(ctx context.Context, request *ListThreadRequest) (*ListThreadResponse, error) {
+	requester, err := interceptor.Identify(ctx, request.Requester)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, pageSize, err := pageFilter(&request.ListMessagesRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListThreadResponse{}
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		if _, err := h.authorizer.Authorize(ctx, store, requester, request.ConversationId, authorization.ActionRead); err != nil {
+			return err
+		}
+
+		root, err := threadRoot(ctx, store, request.ConversationId, request.MessageId)
+		if err != nil {
+			return err
+		}
+		response.Root = converter.ToApiMessage(root)
+
+		filter.ParentMessageId = &root.Id
+		response.ListMessagesResponse, err = listPage(ctx, store, filter, pageSize, requester)
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": request.ConversationId,
+			"message_id":      request.MessageId,
+		}).Errorf("Failed to list thread")
+		return nil, err
+	}
+	return response, nil
+}
+
+// references are the messages a new message replies to and quotes, and the attachments sent with it
+type references struct {
+	parentMessageId *int
+	quotedMessageId *int
+	attachmentIds   []int
+}
+
+func referencesFromContext(ctx context.Context, maxAttachments int) (references, error) {
+	var refs references
+	var err error
+	if refs.parentMessageId, err = messageIdFromContext(ctx, constants.ParentMessageIdMetadataKey); err != nil {
+		return refs, err
+	}
+	if refs.quotedMessageId, err = messageIdFromContext(ctx, constants.QuotedMessageIdMetadataKey); err != nil {
+		return refs, err
+	}
+	refs.attachmentIds, err = attachmentIdsFromContext(ctx, maxAttachments)
+	return refs, err
+}
+
+func messageIdFromContext(ctx context.Context, key string) (*int, error) {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == constants.Zero || values[0] == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(values[0])
+	if err != nil || id <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s must be a positive integer", key)
+	}
+	return &id, nil
+}
+
+// resolve checks that the referenced messages are in the conversation, a reply to a reply joins the thread
+// of its parent since threads are one level deep
+func (r *references) resolve(ctx context.Context, store uow.IStore, conversationId int64) error {
+	if r.parentMessageId != nil {
+		root, err := threadRoot(ctx, store, conversationId, *r.parentMessageId)
+		if err != nil {
+			return err
+		}
+		if root.IsDeleted() {
+			return status.Errorf(codes.FailedPrecondition, "message %d is deleted", root.Id)
+		}
+		r.parentMessageId = &root.Id
+	}
+
+	if r.quotedMessageId != nil {
+		if _, err := conversationMessage(ctx, store, conversationId, *r.quotedMessageId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachments return the attachments the message references, in the order they were sent
+func (r *references) attachments(ctx context.Context, store uow.IStore) ([]models.Attachment, error) {
+	if len(r.attachmentIds) == 0 {
+		return nil, nil
+	}
+
+	records, err := store.Attachments().List(ctx, repository.AttachmentFilter{Ids: r.attachmentIds})
+	if err != nil {
+		return nil, err
+	}
+	byId := lo.KeyBy(records, func(item models.Attachment) int { return item.Id })
+
+	attachments := make([]models.Attachment, 0, len(r.attachmentIds))
+	for _, id := range r.attachmentIds {
+		attachment, ok := byId[id]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "attachment %d not found", id)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// attach links the attachments to the stored message, they must have been uploaded by the sender
+// and not sent with another message
+func (r *references) attach(ctx context.Context, store uow.IStore, message models.Message) error {
+	if len(r.attachmentIds) == 0 {
+		return nil
+	}
+
+	attached, err := store.Attachments().Attach(ctx, r.attachmentIds, message.Sender, message.Id)
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(r.attachmentIds)) {
+		return status.Error(codes.FailedPrecondition, "attachments must be uploaded by the sender and not sent yet")
+	}
+	return nil
+}
+
+// threadRoot return the root of the thread holding the message
+func threadRoot(ctx context.Context, store uow.IStore, conversationId int64, messageId int) (models.Message, error) {
+	message, err := conversationMessage(ctx, store, conversationId, messageId)
+	if err != nil || message.ParentMessageId == nil {
+		return message, err
+	}
+	return conversationMessage(ctx, store, conversationId, *message.ParentMessageId)
+}
+
+// threadsOf return the thread details of the messages keyed by message id, plain messages are left out
+func threadsOf(records []models.Message) map[string]Thread {
+	threads := make(map[string]Thread)
+	for _, record := range records {
+		thread := Thread{ReplyCount: record.ReplyCount}
+		if record.ParentMessageId != nil {
+			thread.ParentMessageId = strconv.Itoa(*record.ParentMessageId)
+		}
+		if record.QuotedMessageId != nil {
+			thread.QuotedMessageId = strconv.Itoa(*record.QuotedMessageId)
+		}
+		if record.LastReplyAt != nil {
+			thread.LastReplyAt = record.LastReplyAt.UnixMilli()
+		}
+		if thread != (Thread{}) {
+			threads[strconv.Itoa(record.Id)] = thread
+		}
+	}
+	return threads
+}
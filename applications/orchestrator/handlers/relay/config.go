@@ -0,0 +1,34 @@
+package relay
+
+import "time"
+
+// Config hold outbox relay config
+type Config struct {
+	PollInterval time.Duration `json:"poll_interval" mapstructure:"poll_interval" yaml:"poll_interval"`
+	BatchSize    int           `json:"batch_size" mapstructure:"batch_size" yaml:"batch_size"`
+	// MaxAttempts is the number of failed publishes after which an event is marked failed
+	MaxAttempts  int           `json:"max_attempts" mapstructure:"max_attempts" yaml:"max_attempts"`
+	RetryBackoff time.Duration `json:"retry_backoff" mapstructure:"retry_backoff" yaml:"retry_backoff"`
+	MaxBackoff   time.Duration `json:"max_backoff" mapstructure:"max_backoff" yaml:"max_backoff"`
+	// ClaimTimeout hides the claimed events from the other relays while they are published, an event
+	// claimed by a relay that died is published again once it lapses
+	ClaimTimeout time.Duration `json:"claim_timeout" mapstructure:"claim_timeout" yaml:"claim_timeout"`
+	// Retention is how long published events are kept, they are deleted every sweep interval once older.
+	// Failed events are kept for inspection, zero keeps every event
+	Retention     time.Duration `json:"retention" mapstructure:"retention" yaml:"retention"`
+	SweepInterval time.Duration `json:"sweep_interval" mapstructure:"sweep_interval" yaml:"sweep_interval"`
+}
+
+// DefaultConfig return a default relay config
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:  100 * time.Millisecond,
+		BatchSize:     100,
+		MaxAttempts:   10,
+		RetryBackoff:  500 * time.Millisecond,
+		MaxBackoff:    time.Minute,
+		ClaimTimeout:  30 * time.Second,
+		Retention:     7 * 24 * time.Hour,
+		SweepInterval: time.Hour,
+	}
+}
@@ -0,0 +1,249 @@
+package relay
+
+import (
+	"context"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/YumikoKawaii/shared/pubsub"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+// Relay publishes committed outbox events to the servers connected by the conversation members
+type Relay struct {
+	cfg          Config
+	connRegistry connection_registry.Registry
+	publisher    pubsub.Publisher
+	worker       uow.IWorker
+	tracer       trace.Tracer
+}
+
+func NewRelay(cfg Config, registry connection_registry.Registry, publisher pubsub.Publisher, worker uow.IWorker, tracer trace.Tracer) *Relay {
+	return &Relay{
+		cfg:          cfg,
+		connRegistry: registry,
+		publisher:    publisher,
+		worker:       worker,
+		tracer:       tracer,
+	}
+}
+
+// Run polls the outbox every poll interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	if r.cfg.Retention > 0 && r.cfg.SweepInterval > 0 {
+		go r.runSweeps(ctx)
+	}
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			relayed, err := r.relayBatch(ctx)
+			if err != nil {
+				logger.WithFields(logger.Fields{
+					"error": err,
+				}).Errorf("Failed to relay outbox batch")
+				break
+			}
+			if relayed < r.cfg.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// runSweeps deletes the events published for longer than the retention every sweep interval until ctx is done
+func (r *Relay) runSweeps(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		before := time.Now().Add(-r.cfg.Retention)
+		for {
+			deleted, err := r.sweepBatch(ctx, before)
+			if err != nil {
+				logger.WithFields(logger.Fields{
+					"error": err,
+				}).Errorf("Failed to delete published outbox events")
+				break
+			}
+			if deleted < int64(r.cfg.BatchSize) {
+				break
+			}
+		}
+	}
+}
+
+func (r *Relay) sweepBatch(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		deleted, err = store.Outbox().DeletePublishedBefore(ctx, before, r.cfg.BatchSize)
+		return err
+	})
+	return deleted, err
+}
+
+// relayBatch publishes one batch of due events. The events are claimed and committed first so that
+// no row stays locked during the network publishes, their results are saved in a second transaction
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	events, err := r.claim(ctx)
+	if err != nil || len(events) == constants.Zero {
+		return constants.Zero, err
+	}
+
+	for idx := range events {
+		event := &events[idx]
+		if err := r.publish(ctx, event); err != nil {
+			r.markRetry(event, err)
+		} else {
+			event.Status = models.OutboxStatusPublished
+			event.PublishedAt = lo.ToPtr(time.Now())
+		}
+	}
+
+	err = r.worker.Do(ctx, func(store uow.IStore) error {
+		for idx := range events {
+			if err := store.Outbox().Update(ctx, &events[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(events), err
+}
+
+// claim selects a batch of due events and pushes them out of the other relays' reach for the claim timeout
+func (r *Relay) claim(ctx context.Context) ([]models.Outbox, error) {
+	var events []models.Outbox
+	err := r.worker.Do(ctx, func(store uow.IStore) error {
+		now := time.Now()
+		var err error
+		events, err = store.Outbox().List(ctx, repository.OutboxFilter{
+			Status:          lo.ToPtr(models.OutboxStatusPending),
+			AvailableBefore: &now,
+			Limit:           r.cfg.BatchSize,
+			SkipLocked:      true,
+		})
+		if err != nil {
+			return err
+		}
+
+		for idx := range events {
+			events[idx].AvailableAt = now.Add(r.cfg.ClaimTimeout)
+			if err := store.Outbox().Update(ctx, &events[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+// publish continues the trace carried by the event, the envelope is re-wrapped so the streamer
+// spans are children of the publish span
+func (r *Relay) publish(ctx context.Context, event *models.Outbox) (err error) {
+	ctx, envelope, err := events.Unwrap(ctx, event.Payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := r.tracer.Start(ctx, "outbox.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.Int64("outbox.id", event.Id),
+			attribute.Int64("conversation.id", event.ConversationId),
+			attribute.String("event.kind", envelope.Kind),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	payload, err := events.Wrap(ctx, envelope.Kind, envelope.Payload)
+	if err != nil {
+		return err
+	}
+
+	var userConversations []models.UserConversation
+	if err := r.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		userConversations, err = store.UserConversations().List(ctx, repository.UserConversationFilter{
+			ConversationId: &event.ConversationId,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	userIdentifications := lo.Map(userConversations, func(item models.UserConversation, _ int) string {
+		return item.UserIdentification
+	})
+
+	servers, err := r.connRegistry.GetServers(ctx, userIdentifications)
+	if err != nil {
+		return err
+	}
+
+	span.SetAttributes(attribute.Int("servers", len(servers)))
+	for _, sv := range servers {
+		topic := constants.GenerateMessagesTopic(sv)
+		if err := r.publisher.Publish(ctx, topic, payload); err != nil {
+			logger.WithFields(logger.Fields{
+				"error":           err,
+				"server":          sv,
+				"outbox_id":       event.Id,
+				"conversation_id": event.ConversationId,
+			}).Errorf("Failed to publish outbox event")
+			return err
+		}
+	}
+	return nil
+}
+
+// markRetry schedules the next attempt with an exponential backoff, or gives up after max attempts
+func (r *Relay) markRetry(event *models.Outbox, err error) {
+	event.Attempts++
+	event.LastError = err.Error()
+	if event.Attempts >= r.cfg.MaxAttempts {
+		event.Status = models.OutboxStatusFailed
+		logger.WithFields(logger.Fields{
+			"error":     err,
+			"outbox_id": event.Id,
+			"attempts":  event.Attempts,
+		}).Errorf("Outbox event exhausted its attempts")
+		return
+	}
+
+	backoff := r.cfg.RetryBackoff << (event.Attempts - 1)
+	if backoff <= 0 || backoff > r.cfg.MaxBackoff {
+		backoff = r.cfg.MaxBackoff
+	}
+	event.AvailableAt = time.Now().Add(backoff)
+}
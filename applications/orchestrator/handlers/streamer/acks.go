@@ -0,0 +1,125 @@
+package streamer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// Ack confirms that a device got every message of the conversation up to MessageId
+type Ack struct {
+	ConversationId int64  `json:"conversation_id"`
+	MessageId      string `json:"message_id"`
+}
+
+type AckMessagesRequest struct {
+	UserId string `json:"user_id"`
+	Acks   []Ack  `json:"acks"`
+}
+
+func (r *AckMessagesRequest) Validate() error {
+	if len(r.Acks) == 0 {
+		return errors.New("acks must not be empty")
+	}
+	for _, ack := range r.Acks {
+		if ack.ConversationId <= 0 {
+			return errors.New("conversation_id must be positive")
+		}
+		if id, err := strconv.Atoi(ack.MessageId); err != nil || id <= 0 {
+			return errors.New("message_id must be a positive integer")
+		}
+	}
+	return nil
+}
+
+// AckMessagesResponse holds the delivery cursor of every acked conversation after the acks are applied
+type AckMessagesResponse struct {
+	Acks []Ack `json:"acks"`
+}
+
+func decodeAckMessagesRequest(r *http.Request, _ map[string]string) (interface{}, error) {
+	request := &AckMessagesRequest{}
+	return request, server.DecodeJSON(r, request)
+}
+
+// AckMessages advances the delivery cursors of the user, acks are cumulative and an ack behind the cursor
+// is ignored. Every advanced cursor is published as a DELIVERED receipt to the conversation
+func (h *Handler) AckMessages(ctx context.Context, request *AckMessagesRequest) (*AckMessagesResponse, error) {
+	userIdentification, err := interceptor.Identify(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the furthest ack of each conversation matters
+	furthest := make(map[int64]int)
+	for _, ack := range request.Acks {
+		messageId, _ := strconv.Atoi(ack.MessageId)
+		furthest[ack.ConversationId] = max(furthest[ack.ConversationId], messageId)
+	}
+
+	response := &AckMessagesResponse{Acks: make([]Ack, 0, len(furthest))}
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		for conversationId, messageId := range furthest {
+			if _, err := store.Messages().Get(ctx, repository.MessageFilter{
+				Id:             &messageId,
+				ConversationId: &conversationId,
+			}); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return status.Errorf(codes.NotFound, "message %d not found in conversation %d", messageId, conversationId)
+				}
+				return err
+			}
+
+			advanced, err := store.UserConversations().AdvanceLastDelivered(ctx, conversationId, userIdentification, messageId, time.Now().UTC().Truncate(time.Millisecond))
+			if err != nil {
+				return err
+			}
+
+			userConversation, err := store.UserConversations().Get(ctx, repository.UserConversationFilter{
+				ConversationId:     &conversationId,
+				UserIdentification: &userIdentification,
+			})
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return status.Errorf(codes.PermissionDenied, "%s is not a member of conversation %d", userIdentification, conversationId)
+			}
+			if err != nil {
+				return err
+			}
+
+			response.Acks = append(response.Acks, Ack{
+				ConversationId: conversationId,
+				MessageId:      strconv.Itoa(userConversation.LastDeliveredMessageId),
+			})
+			if advanced {
+				if err := events.Enqueue(ctx, store, conversationId, events.KindReceipt, converter.ToApiReceipt(userConversation, api.MessageStatus_DELIVERED)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":               err,
+			"user_identification": userIdentification,
+			"conversations":       lo.Keys(furthest),
+		}).Errorf("Failed to ack messages")
+		return nil, err
+	}
+
+	return response, nil
+}
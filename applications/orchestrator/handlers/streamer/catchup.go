@@ -0,0 +1,127 @@
+package streamer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/converter"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+// catchUp sends what the client missed before the stream goes live and return the ids of the messages sent,
+// a client with a resume cursor gets up to MaxReplay messages past it, others get the messages they have not acked
+func (h *Handler) catchUp(ctx context.Context, stream grpc.ServerStreamingServer[api.Message], userIdentification string) (map[string]struct{}, error) {
+	cursor, err := resumeCursorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make(map[string]struct{})
+	send := func(records []models.Message) error {
+		for _, record := range records {
+			message := converter.ToApiMessage(record)
+			if err := stream.Send(message); err != nil {
+				logger.WithFields(logger.Fields{
+					"error":               err,
+					"user_identification": userIdentification,
+				}).Errorf("Failed to send missed message")
+				return err
+			}
+			sent[message.MessageId] = struct{}{}
+		}
+		return nil
+	}
+
+	if cursor == nil {
+		records, err := h.listMissed(ctx, userIdentification, func(store uow.IStore) ([]models.Message, error) {
+			return store.Messages().Undelivered(ctx, userIdentification, h.cfg.RedeliveryLimit)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sent, send(records)
+	}
+
+	// the pages are read before anything is sent so that a client past MaxReplay gets the replay gap header
+	// instead, the session keeps the live messages meanwhile
+	records := make([]models.Message, constants.Zero)
+	afterId := constants.Zero
+	for {
+		page, err := h.listMissed(ctx, userIdentification, func(store uow.IStore) ([]models.Message, error) {
+			return store.Messages().Replay(ctx, userIdentification, *cursor, afterId, h.cfg.ReplayPageSize)
+		})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if len(records) > h.cfg.MaxReplay {
+			logger.WithFields(logger.Fields{
+				"user_identification": userIdentification,
+				"max_replay":          h.cfg.MaxReplay,
+			}).Infof("Client missed more than can be replayed, sending a replay gap")
+			return sent, stream.SendHeader(metadata.Pairs(constants.ReplayGapMetadataKey, "true"))
+		}
+		if len(page) < h.cfg.ReplayPageSize {
+			return sent, send(records)
+		}
+		afterId = page[len(page)-1].Id
+	}
+}
+
+func (h *Handler) listMissed(ctx context.Context, userIdentification string, list func(store uow.IStore) ([]models.Message, error)) ([]models.Message, error) {
+	records := make([]models.Message, constants.Zero)
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		records, err = list(store)
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":               err,
+			"user_identification": userIdentification,
+		}).Errorf("Failed to list missed messages")
+		return nil, err
+	}
+	return records, nil
+}
+
+// resumeCursorFromContext parses the resume cursor of the stream, nil when the client sent none
+func resumeCursorFromContext(ctx context.Context) (*repository.ResumeCursor, error) {
+	values := metadata.ValueFromIncomingContext(ctx, constants.ResumeCursorMetadataKey)
+	if len(values) == constants.Zero || values[0] == "" {
+		return nil, nil
+	}
+
+	cursor := &repository.ResumeCursor{Conversations: make(map[int64]int)}
+	for _, part := range strings.Split(values[0], ",") {
+		conversation, message, scoped := strings.Cut(strings.TrimSpace(part), ":")
+		if !scoped {
+			messageId, err := strconv.Atoi(conversation)
+			if err != nil || messageId < 0 || cursor.Global != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s", constants.ResumeCursorMetadataKey)
+			}
+			cursor.Global = &messageId
+			continue
+		}
+
+		conversationId, err := strconv.ParseInt(conversation, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s", constants.ResumeCursorMetadataKey)
+		}
+		messageId, err := strconv.Atoi(message)
+		if err != nil || messageId < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s", constants.ResumeCursorMetadataKey)
+		}
+		cursor.Conversations[conversationId] = messageId
+	}
+	return cursor, nil
+}
@@ -0,0 +1,42 @@
+package streamer
+
+import (
+	"os"
+)
+
+const (
+	defaultSessionBufferSize = 256
+	defaultRedeliveryLimit   = 1000
+	defaultReplayPageSize    = 500
+	defaultMaxReplay         = 2000
+	defaultMaxBacklog        = 1024
+)
+
+// Config hold streamer node config
+type Config struct {
+	// NodeId identifies this streamer node; receivers publish to messages.<NodeId>. A streamer suffixes it
+	// per process, see connection_registry.InstanceId
+	NodeId            string `json:"node_id" mapstructure:"node_id" yaml:"node_id"`
+	SessionBufferSize int    `json:"session_buffer_size" mapstructure:"session_buffer_size" yaml:"session_buffer_size"`
+	// RedeliveryLimit caps the unacked messages sent when a stream opens
+	RedeliveryLimit int `json:"redelivery_limit" mapstructure:"redelivery_limit" yaml:"redelivery_limit"`
+	// ReplayPageSize is the number of messages read at once when replaying from a resume cursor
+	ReplayPageSize int `json:"replay_page_size" mapstructure:"replay_page_size" yaml:"replay_page_size"`
+	// MaxReplay caps the messages replayed from a resume cursor, a client further behind gets a replay gap
+	MaxReplay int `json:"max_replay" mapstructure:"max_replay" yaml:"max_replay"`
+	// MaxBacklog caps the live messages kept while catching up, the stream is closed once it is exceeded
+	MaxBacklog int `json:"max_backlog" mapstructure:"max_backlog" yaml:"max_backlog"`
+}
+
+// DefaultConfig return a default streamer config, the node id falls back to the hostname
+func DefaultConfig() Config {
+	hostname, _ := os.Hostname()
+	return Config{
+		NodeId:            hostname,
+		SessionBufferSize: defaultSessionBufferSize,
+		RedeliveryLimit:   defaultRedeliveryLimit,
+		ReplayPageSize:    defaultReplayPageSize,
+		MaxReplay:         defaultMaxReplay,
+		MaxBacklog:        defaultMaxBacklog,
+	}
+}
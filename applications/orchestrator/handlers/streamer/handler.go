@@ -1,24 +1,225 @@
 package streamer
 
 import (
+	"context"
+
 	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
 	"github.com/YumikoKawaii/shared/logger"
+	"github.com/YumikoKawaii/shared/pubsub"
+	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/events"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
 )
 
+var dispatchedKinds = []string{events.KindMessage, events.KindReceipt, events.KindEdit, events.KindDelete, events.KindReaction}
+
 type Handler struct {
 	api.StreamerServer
+	cfg          Config
+	connRegistry connection_registry.Registry
+	subscriber   pubsub.Subscriber
+	worker       uow.IWorker
+	sessions     *sessionRegistry
+	tracer       trace.Tracer
+}
+
+func NewHandler(cfg Config, registry connection_registry.Registry, subscriber pubsub.Subscriber, worker uow.IWorker, tracer trace.Tracer) *Handler {
+	return &Handler{
+		cfg:          cfg,
+		connRegistry: registry,
+		subscriber:   subscriber,
+		worker:       worker,
+		sessions:     newSessionRegistry(),
+		tracer:       tracer,
+	}
 }
 
-func NewHandler() *Handler {
-	return &Handler{}
+// Start subscribes to the topic of this node, it should be called once before serving
+func (h *Handler) Start(ctx context.Context) {
+	topic := constants.GenerateMessagesTopic(h.cfg.NodeId)
+	logger.WithFields(logger.Fields{
+		"node_id": h.cfg.NodeId,
+		"topic":   topic,
+	}).Infof("Subscribing to node topic")
+
+	go h.subscriber.Consume(ctx, topic, h.dispatch)
 }
 
 func (h *Handler) ReceiveMessages(request *api.ReceiveMessagesRequest, stream grpc.ServerStreamingServer[api.Message]) error {
-	logger.Infof("Stream opened for receiving messages")
+	ctx := stream.Context()
+	userIdentification, err := interceptor.Identify(ctx, request.UserId)
+	if err != nil {
+		return err
+	}
+
+	if err := h.connRegistry.Register(ctx, userIdentification, h.cfg.NodeId); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":               err,
+			"user_identification": userIdentification,
+		}).Errorf("Failed to register connection")
+		return err
+	}
+	defer h.unregister(userIdentification)
+
+	// the session is added before catching up so that nothing published meanwhile is missed
+	s := newSession(userIdentification, h.cfg.SessionBufferSize, h.cfg.MaxBacklog)
+	h.sessions.add(s)
+	defer h.sessions.remove(s)
+
+	caughtUp, err := h.catchUp(ctx, stream, userIdentification)
+	if err != nil {
+		return err
+	}
+
+	forward := func(d delivery) error {
+		// a message both replayed and received live is only sent once, its edits and deletion still are
+		if _, ok := caughtUp[d.message.MessageId]; ok && d.kind == events.KindMessage {
+			return nil
+		}
+		if err := h.send(ctx, stream, d); err != nil {
+			logger.WithFields(logger.Fields{
+				"error":               err,
+				"user_identification": userIdentification,
+			}).Errorf("Failed to send message to stream")
+			return err
+		}
+		return nil
+	}
+
+	for _, d := range s.goLive() {
+		if err := forward(d); err != nil {
+			return err
+		}
+	}
+
+	logger.WithFields(logger.Fields{
+		"user_identification": userIdentification,
+		"node_id":             h.cfg.NodeId,
+		"caught_up":           len(caughtUp),
+	}).Infof("Stream opened for receiving messages")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.WithFields(logger.Fields{
+				"user_identification": userIdentification,
+			}).Infof("Stream closed by client")
+			return nil
+		case <-s.overflow:
+			logger.WithFields(logger.Fields{
+				"user_identification": userIdentification,
+			}).Warnf("Stream fell behind, closing it")
+			return status.Error(codes.Unavailable, "stream fell behind, reconnect with a resume cursor")
+		case d := <-s.messages:
+			if err := forward(d); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// send writes a message to the stream in a span continuing the trace of its dispatch,
+// linked to the span of the stream
+func (h *Handler) send(ctx context.Context, stream grpc.ServerStreamingServer[api.Message], d delivery) error {
+	_, span := h.tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), d.parent), "streamer.send",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(
+			attribute.Int64("conversation.id", d.message.ConversationId),
+			attribute.String("message.id", d.message.MessageId),
+		),
+	)
+	defer span.End()
+
+	if err := stream.Send(d.message); err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// unregister releases the connection of a closed stream, the stream context is already done at this point
+func (h *Handler) unregister(userIdentification string) {
+	if err := h.connRegistry.Unregister(context.Background(), userIdentification, h.cfg.NodeId); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":               err,
+			"user_identification": userIdentification,
+		}).Errorf("Failed to unregister connection")
+	}
+}
+
+// dispatch routes a message, receipt, edit, deletion or reaction published to this node to the streams of the conversation members
+func (h *Handler) dispatch(bytes []byte) error {
+	ctx, envelope, err := events.Unwrap(context.Background(), bytes)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error": err,
+		}).Errorf("Failed to unwrap event")
+		return err
+	}
+	if !lo.Contains(dispatchedKinds, envelope.Kind) {
+		logger.WithFields(logger.Fields{
+			"kind": envelope.Kind,
+		}).Warnf("Unknown event kind, skipping")
+		return nil
+	}
+
+	message := &api.Message{}
+	if err := proto.Unmarshal(envelope.Payload, message); err != nil {
+		logger.WithFields(logger.Fields{
+			"error": err,
+		}).Errorf("Failed to unmarshal message")
+		return err
+	}
+
+	if h.sessions.empty() {
+		return nil
+	}
+
+	ctx, span := h.tracer.Start(ctx, "streamer.dispatch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("node.id", h.cfg.NodeId),
+			attribute.Int64("conversation.id", message.ConversationId),
+			attribute.String("message.id", message.MessageId),
+		),
+	)
+	defer span.End()
+
+	userConversations := make([]models.UserConversation, constants.Zero)
+	if err := h.worker.Do(ctx, func(store uow.IStore) error {
+		var err error
+		userConversations, err = store.UserConversations().List(ctx, repository.UserConversationFilter{
+			ConversationId: &message.ConversationId,
+		})
+		return err
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": message.ConversationId,
+		}).Errorf("Failed to list user conversations")
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return err
+	}
 
-	// TODO: Implement message streaming logic
-	logger.Warnf("ReceiveMessages not yet implemented")
+	d := delivery{parent: span.SpanContext(), kind: envelope.Kind, message: message}
+	for _, userConversation := range userConversations {
+		h.sessions.deliver(userConversation.UserIdentification, d)
+	}
 
 	return nil
 }
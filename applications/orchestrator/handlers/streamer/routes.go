@@ -0,0 +1,20 @@
+package streamer
+
+import (
+	"net/http"
+
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// Routes return the streamer methods served on the gateway mux
+func (h *Handler) Routes() []server.Route {
+	return []server.Route{
+		{
+			Method:     http.MethodPost,
+			Pattern:    "/api/v1/acks",
+			FullMethod: "/orchestrator.Streamer/AckMessages",
+			Decode:     decodeAckMessagesRequest,
+			Handler:    server.Unary(h.AckMessages),
+		},
+	}
+}
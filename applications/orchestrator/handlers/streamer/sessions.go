@@ -0,0 +1,140 @@
+package streamer
+
+import (
+	"sync"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// delivery is a message routed to a session with the event kind and the span that dispatched it
+type delivery struct {
+	parent  trace.SpanContext
+	kind    string
+	message *api.Message
+}
+
+// session is a single open ReceiveMessages stream, it starts catching up and keeps every live
+// message in the backlog until goLive is called
+type session struct {
+	userIdentification string
+	messages           chan delivery
+	maxBacklog         int
+	// overflow is closed once a message could not be queued, the stream then ends so that the client
+	// resumes from its cursor instead of acking past the dropped message
+	overflow chan struct{}
+
+	mu         sync.Mutex
+	catchingUp bool
+	backlog    []delivery
+	overflowed bool
+}
+
+func newSession(userIdentification string, bufferSize int, maxBacklog int) *session {
+	return &session{
+		userIdentification: userIdentification,
+		messages:           make(chan delivery, bufferSize),
+		maxBacklog:         maxBacklog,
+		overflow:           make(chan struct{}),
+		catchingUp:         true,
+	}
+}
+
+// offer queues the message without blocking, it reports false when the buffer or the backlog is full and
+// closes the overflow channel. Nothing is queued after an overflow so the client never gets a message past the gap
+func (s *session) offer(d delivery) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.overflowed {
+		return false
+	}
+	if s.catchingUp && len(s.backlog) < s.maxBacklog {
+		s.backlog = append(s.backlog, d)
+		return true
+	}
+	if !s.catchingUp {
+		select {
+		case s.messages <- d:
+			return true
+		default:
+		}
+	}
+
+	s.overflowed = true
+	close(s.overflow)
+	return false
+}
+
+// goLive ends the catch up and return the messages received meanwhile, they come before anything
+// sent to the messages channel afterward
+func (s *session) goLive() []delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backlog := s.backlog
+	s.catchingUp, s.backlog = false, nil
+	return backlog
+}
+
+// sessionRegistry keeps track of the streams opened on this node, grouped by user
+type sessionRegistry struct {
+	mu     sync.RWMutex
+	byUser map[string]map[*session]struct{}
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{
+		byUser: make(map[string]map[*session]struct{}),
+	}
+}
+
+func (r *sessionRegistry) add(s *session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions, ok := r.byUser[s.userIdentification]
+	if !ok {
+		sessions = make(map[*session]struct{})
+		r.byUser[s.userIdentification] = sessions
+	}
+	sessions[s] = struct{}{}
+}
+
+func (r *sessionRegistry) remove(s *session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions, ok := r.byUser[s.userIdentification]
+	if !ok {
+		return
+	}
+	delete(sessions, s)
+	if len(sessions) == 0 {
+		delete(r.byUser, s.userIdentification)
+	}
+}
+
+func (r *sessionRegistry) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.byUser) == 0
+}
+
+// deliver hands the message to every stream of the user without blocking the subscriber,
+// a stream whose buffer is full is closed
+func (r *sessionRegistry) deliver(userIdentification string, d delivery) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for s := range r.byUser[userIdentification] {
+		if !s.offer(d) {
+			logger.WithFields(logger.Fields{
+				"user_identification": userIdentification,
+				"conversation_id":     d.message.ConversationId,
+			}).Warnf("Session buffer full, closing stream")
+		}
+	}
+}
@@ -0,0 +1,5 @@
+-- Drop the initial schema, children first because of the foreign keys
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS user_conversations;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,2 @@
+-- Drop the outbox table, pending events are lost
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,19 @@
+-- Create outbox table, rows are written with the message and published by the relay after commit
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id              BIGINT auto_increment PRIMARY KEY,
+    conversation_id BIGINT NOT NULL,
+    payload         BLOB NOT NULL,
+    status          VARCHAR (20) NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    available_at    TIMESTAMP (3) NOT NULL DEFAULT CURRENT_TIMESTAMP (3),
+    published_at    TIMESTAMP NULL,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE
+    CURRENT_TIMESTAMP,
+    INDEX idx_status_available_at ( status, available_at )
+    )
+    engine = innodb
+    DEFAULT charset = utf8mb4
+    COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+-- Remove client_message_id from messages
+ALTER TABLE messages
+    DROP INDEX unique_sender_conversation_client_message,
+    DROP COLUMN client_message_id;
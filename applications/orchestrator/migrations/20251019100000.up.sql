@@ -0,0 +1,4 @@
+-- Add client_message_id to messages so that retried sends are deduplicated
+ALTER TABLE messages
+    ADD COLUMN client_message_id VARCHAR (36) NULL AFTER type,
+    ADD UNIQUE KEY unique_sender_conversation_client_message ( sender, conversation_id, client_message_id );
@@ -0,0 +1,4 @@
+-- Store message timestamps with second precision again
+ALTER TABLE messages
+    MODIFY created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    MODIFY updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
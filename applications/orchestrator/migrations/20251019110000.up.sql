@@ -0,0 +1,4 @@
+-- Store message timestamps with millisecond precision, created_at is the authoritative message time
+ALTER TABLE messages
+    MODIFY created_at TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3),
+    MODIFY updated_at TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3) ON UPDATE CURRENT_TIMESTAMP (3);
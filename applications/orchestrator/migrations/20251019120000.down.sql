@@ -0,0 +1,3 @@
+-- Remove post_policy from conversations
+ALTER TABLE conversations
+    DROP COLUMN post_policy;
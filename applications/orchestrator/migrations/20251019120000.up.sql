@@ -0,0 +1,3 @@
+-- Add post_policy to conversations, announcement channels only let admins post
+ALTER TABLE conversations
+    ADD COLUMN post_policy VARCHAR (20) NOT NULL DEFAULT 'everyone' AFTER id;
@@ -0,0 +1,4 @@
+-- Remove the read cursor of each member
+ALTER TABLE user_conversations
+    DROP COLUMN last_read_at,
+    DROP COLUMN last_read_message_id;
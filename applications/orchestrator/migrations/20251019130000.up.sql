@@ -0,0 +1,4 @@
+-- Add the read cursor of each member, messages after last_read_message_id are unread
+ALTER TABLE user_conversations
+    ADD COLUMN last_read_message_id INT NOT NULL DEFAULT 0 AFTER role,
+    ADD COLUMN last_read_at TIMESTAMP(3) NULL AFTER last_read_message_id;
@@ -0,0 +1,4 @@
+-- Remove the delivery cursor of each member
+ALTER TABLE user_conversations
+    DROP COLUMN last_delivered_at,
+    DROP COLUMN last_delivered_message_id;
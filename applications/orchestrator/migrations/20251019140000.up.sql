@@ -0,0 +1,8 @@
+-- Add the delivery cursor of each member, messages after last_delivered_message_id are redelivered on reconnect
+ALTER TABLE user_conversations
+    ADD COLUMN last_delivered_message_id INT NOT NULL DEFAULT 0 AFTER role,
+    ADD COLUMN last_delivered_at TIMESTAMP(3) NULL AFTER last_delivered_message_id;
+
+-- Existing members are considered up to date so that reconnecting does not replay the whole history
+UPDATE user_conversations AS uc
+SET uc.last_delivered_message_id = (SELECT COALESCE(MAX(m.id), 0) FROM messages AS m WHERE m.conversation_id = uc.conversation_id);
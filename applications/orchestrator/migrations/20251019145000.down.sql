@@ -0,0 +1,13 @@
+-- Narrow conversation ids back to INT, messages no longer reference their conversation
+ALTER TABLE messages
+    DROP FOREIGN KEY fk_messages_conversation_id;
+
+ALTER TABLE user_conversations
+    DROP FOREIGN KEY fk_user_conversations_conversation_id;
+
+ALTER TABLE conversations
+    MODIFY id INT auto_increment;
+
+ALTER TABLE user_conversations
+    MODIFY conversation_id INT NOT NULL,
+    ADD CONSTRAINT user_conversations_ibfk_2 FOREIGN KEY ( conversation_id ) REFERENCES conversations ( id ) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+-- Widen conversation ids to BIGINT like the API and messages.conversation_id, then reference the conversation
+-- from messages. The foreign key of user_conversations is dropped around the change, its generated name comes
+-- from the initial schema
+ALTER TABLE user_conversations
+    DROP FOREIGN KEY user_conversations_ibfk_2;
+
+ALTER TABLE conversations
+    MODIFY id BIGINT auto_increment;
+
+ALTER TABLE user_conversations
+    MODIFY conversation_id BIGINT NOT NULL,
+    ADD CONSTRAINT fk_user_conversations_conversation_id FOREIGN KEY ( conversation_id ) REFERENCES conversations ( id ) ON DELETE CASCADE;
+
+ALTER TABLE messages
+    ADD CONSTRAINT fk_messages_conversation_id FOREIGN KEY ( conversation_id ) REFERENCES conversations ( id ) ON DELETE CASCADE;
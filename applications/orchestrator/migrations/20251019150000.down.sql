@@ -0,0 +1,6 @@
+-- Remove message revisions, edits and tombstones
+DROP TABLE IF EXISTS message_revisions;
+
+ALTER TABLE messages
+    DROP COLUMN deleted_at,
+    DROP COLUMN edited_at;
@@ -0,0 +1,19 @@
+-- Add edits and tombstones to messages, a deleted message is kept with deleted_at set
+ALTER TABLE messages
+    ADD COLUMN edited_at TIMESTAMP(3) NULL AFTER updated_at,
+    ADD COLUMN deleted_at TIMESTAMP(3) NULL AFTER edited_at;
+
+-- Keep the content a message had before each edit
+CREATE TABLE IF NOT EXISTS message_revisions
+(
+    id         INT auto_increment PRIMARY KEY,
+    message_id INT NOT NULL,
+    content    TEXT NOT NULL,
+    edited_by  VARCHAR (255) NOT NULL,
+    created_at TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3),
+    FOREIGN KEY ( message_id ) REFERENCES messages ( id ) ON DELETE CASCADE,
+    INDEX idx_message_id ( message_id )
+    )
+    engine = innodb
+    DEFAULT charset = utf8mb4
+    COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,16 @@
+-- Create message_reactions table, emojis are compared byte for byte since unicode collations equate most of them
+CREATE TABLE IF NOT EXISTS message_reactions
+(
+    id                  INT auto_increment PRIMARY KEY,
+    message_id          INT NOT NULL,
+    user_identification VARCHAR (255) NOT NULL,
+    emoji               VARCHAR (64) COLLATE utf8mb4_bin NOT NULL,
+    created_at          TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3),
+    FOREIGN KEY ( message_id ) REFERENCES messages ( id ) ON DELETE CASCADE,
+    FOREIGN KEY ( user_identification ) REFERENCES users ( identification ) ON DELETE CASCADE,
+    INDEX idx_user_identification ( user_identification ),
+    UNIQUE KEY unique_message_user_emoji ( message_id, user_identification, emoji )
+    )
+    engine = innodb
+    DEFAULT charset = utf8mb4
+    COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+-- Remove threads and quotes
+ALTER TABLE messages
+    DROP INDEX idx_parent_message_id,
+    DROP COLUMN last_reply_at,
+    DROP COLUMN reply_count,
+    DROP COLUMN quoted_message_id,
+    DROP COLUMN parent_message_id;
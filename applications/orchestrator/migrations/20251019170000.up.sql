@@ -0,0 +1,7 @@
+-- Add threads and quotes, a reply points to the root of its thread which counts its replies
+ALTER TABLE messages
+    ADD COLUMN parent_message_id INT NULL AFTER client_message_id,
+    ADD COLUMN quoted_message_id INT NULL AFTER parent_message_id,
+    ADD COLUMN reply_count INT NOT NULL DEFAULT 0 AFTER quoted_message_id,
+    ADD COLUMN last_reply_at TIMESTAMP(3) NULL AFTER reply_count,
+    ADD INDEX idx_parent_message_id ( parent_message_id );
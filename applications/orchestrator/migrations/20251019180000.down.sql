@@ -0,0 +1,2 @@
+-- Remove attachments
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,24 @@
+-- Create attachments table, an attachment is pending until its upload is verified
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id          INT auto_increment PRIMARY KEY,
+    uploader    VARCHAR (255) NOT NULL,
+    message_id  INT NULL,
+    storage_key VARCHAR (255) NOT NULL UNIQUE,
+    file_name   VARCHAR (255) NOT NULL,
+    mime_type   VARCHAR (255) NOT NULL,
+    size        BIGINT NOT NULL,
+    checksum    VARCHAR (64) NOT NULL DEFAULT '',
+    width       INT NULL,
+    height      INT NULL,
+    status      VARCHAR (20) NOT NULL,
+    created_at  TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3),
+    updated_at  TIMESTAMP (3) DEFAULT CURRENT_TIMESTAMP (3) ON UPDATE CURRENT_TIMESTAMP (3),
+    FOREIGN KEY ( uploader ) REFERENCES users ( identification ) ON DELETE CASCADE,
+    FOREIGN KEY ( message_id ) REFERENCES messages ( id ) ON DELETE SET NULL,
+    INDEX idx_uploader ( uploader ),
+    INDEX idx_message_id ( message_id )
+    )
+    engine = innodb
+    DEFAULT charset = utf8mb4
+    COLLATE = utf8mb4_unicode_ci;
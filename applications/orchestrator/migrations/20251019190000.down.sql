@@ -0,0 +1,3 @@
+-- Remove the payload of structured messages
+ALTER TABLE messages
+    DROP COLUMN payload;
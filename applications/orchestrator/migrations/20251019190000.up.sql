@@ -0,0 +1,3 @@
+-- Add the JSON payload of structured messages, content keeps a plain text summary of it
+ALTER TABLE messages
+    ADD COLUMN payload JSON NULL AFTER type;
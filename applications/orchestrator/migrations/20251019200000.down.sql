@@ -0,0 +1,3 @@
+-- Remove the full-text index on the message contents
+ALTER TABLE messages
+    DROP INDEX ft_content;
@@ -0,0 +1,3 @@
+-- Add a full-text index on the message contents, the ngram parser also splits the languages written without spaces
+ALTER TABLE messages
+    ADD FULLTEXT INDEX ft_content (content) WITH PARSER ngram;
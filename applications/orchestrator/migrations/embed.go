@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the migration files, <version>.up.sql applies a version and <version>.down.sql reverts it
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,63 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/migrations"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/migrator"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/schemacheck"
+)
+
+// mysqlDSNEnv names an empty MySQL database the test may migrate, e.g. root:root@tcp(127.0.0.1:3306)/orchestrator_test
+const mysqlDSNEnv = "ORCHESTRATOR_TEST_MYSQL_DSN"
+
+// TestMigrationsMatchModels applies every migration, checks the schema against the models, then reverts
+// everything and applies it again so that the down files are exercised too
+func TestMigrationsMatchModels(t *testing.T) {
+	dsn := os.Getenv(mysqlDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", mysqlDSNEnv)
+	}
+
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ParseTime = true
+	sqlDB, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	all, err := migrator.Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	m := migrator.NewMigrator(sqlDB, all)
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemacheck.RequireMatch(t, db, models.All()...)
+
+	if err := m.To(ctx, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	schemacheck.RequireMatch(t, db, models.All()...)
+}
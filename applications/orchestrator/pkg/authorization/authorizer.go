@@ -0,0 +1,77 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+type Action string
+
+const (
+	ActionRead          Action = "read"
+	ActionPost          Action = "post"
+	ActionManageMembers Action = "manage members"
+)
+
+// Authorizer checks that a user may perform an action in a conversation, it return the membership
+// of the user, NotFound for an unknown conversation and PermissionDenied otherwise
+type Authorizer interface {
+	Authorize(ctx context.Context, store uow.IStore, userIdentification string, conversationId int64, action Action) (models.UserConversation, error)
+}
+
+func NewAuthorizer() Authorizer {
+	return &roleAuthorizer{}
+}
+
+// roleAuthorizer grants an action to the members holding at least the role it requires
+type roleAuthorizer struct{}
+
+func (a *roleAuthorizer) Authorize(ctx context.Context, store uow.IStore, userIdentification string, conversationId int64, action Action) (models.UserConversation, error) {
+	conversation, err := store.Conversations().Get(ctx, repository.ConversationFilter{Id: &conversationId})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.UserConversation{}, status.Errorf(codes.NotFound, "conversation %d not found", conversationId)
+		}
+		return models.UserConversation{}, err
+	}
+
+	userConversation, err := store.UserConversations().Get(ctx, repository.UserConversationFilter{
+		ConversationId:     &conversationId,
+		UserIdentification: &userIdentification,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userConversation, status.Errorf(codes.PermissionDenied, "%s is not a member of conversation %d", userIdentification, conversationId)
+		}
+		return userConversation, err
+	}
+
+	if required := requiredRole(conversation, action); !models.AtLeast(userConversation.Role, required) {
+		return userConversation, status.Errorf(codes.PermissionDenied, "%s requires the %s role in conversation %d", action, required, conversationId)
+	}
+	return userConversation, nil
+}
+
+// requiredRole return the lowest role allowed to perform the action in the conversation
+func requiredRole(conversation models.Conversation, action Action) string {
+	switch action {
+	case ActionPost:
+		if conversation.PostPolicy == models.PostPolicyAdmins {
+			return models.RoleAdmin
+		}
+		return models.RoleMember
+	case ActionRead:
+		return models.RoleReader
+	case ActionManageMembers:
+		return models.RoleAdmin
+	default:
+		return models.RoleOwner
+	}
+}
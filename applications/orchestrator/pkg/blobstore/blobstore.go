@@ -0,0 +1,55 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignedURL is a URL granting a single operation on a blob until it expires. An upload is sent with Method,
+// a POST as a multipart form holding Fields before the file
+type SignedURL struct {
+	URL       string
+	Method    string
+	Fields    map[string]string
+	ExpiresAt time.Time
+}
+
+// BlobStore keeps attachment contents, clients transfer them directly through signed URLs
+type BlobStore interface {
+	// UploadURL return a URL accepting an upload of the blob of at most size bytes
+	UploadURL(ctx context.Context, key string, size int64) (SignedURL, error)
+	// DownloadURL return a URL serving a GET of the blob
+	DownloadURL(ctx context.Context, key string) (SignedURL, error)
+	// Open return the content of the blob, ErrNotFound when it was not uploaded
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Move renames the blob, the upload URLs signed for the previous key no longer reach it. It return
+	// ErrNotFound when the blob was not uploaded
+	Move(ctx context.Context, from string, to string) error
+}
+
+// URLServer is implemented by the stores serving their own signed URLs, the handlers are mounted
+// on the gateway mux at BlobPattern
+type URLServer interface {
+	ServeUpload(w http.ResponseWriter, r *http.Request, pathParams map[string]string)
+	ServeDownload(w http.ResponseWriter, r *http.Request, pathParams map[string]string)
+}
+
+const BlobPattern = "/api/v1/blobs/{key}"
+
+var ErrNotFound = errors.New("blob not found")
+
+// NewBlobStore return the blob store of the configured kind
+func NewBlobStore(ctx context.Context, cfg Config) (BlobStore, error) {
+	switch cfg.Kind {
+	case KindLocal:
+		return NewLocalStore(cfg.Local, cfg.URLExpiry)
+	case KindS3:
+		return NewS3Store(ctx, cfg.S3, cfg.URLExpiry)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,56 @@
+package blobstore
+
+import "time"
+
+const (
+	// KindLocal keeps the blobs in a directory and serves the signed URLs on the gateway
+	KindLocal = "local"
+	// KindS3 keeps the blobs in an S3 compatible bucket, such as a MinIO server
+	KindS3 = "s3"
+)
+
+// Config hold the blob store used for attachments
+type Config struct {
+	Kind string `json:"kind" mapstructure:"kind" yaml:"kind"`
+	// URLExpiry is how long upload and download URLs stay valid
+	URLExpiry time.Duration `json:"url_expiry" mapstructure:"url_expiry" yaml:"url_expiry"`
+	Local     LocalConfig   `json:"local" mapstructure:"local" yaml:"local"`
+	S3        S3Config      `json:"s3" mapstructure:"s3" yaml:"s3"`
+}
+
+type LocalConfig struct {
+	Directory string `json:"directory" mapstructure:"directory" yaml:"directory"`
+	// BaseURL is the address of the HTTP gateway the signed URLs point to
+	BaseURL string `json:"base_url" mapstructure:"base_url" yaml:"base_url"`
+	// SigningKey signs the URLs, a random key is used when empty so URLs do not survive a restart
+	SigningKey string `json:"signing_key" mapstructure:"signing_key" yaml:"signing_key"`
+	// MaxSize bounds the body of an upload, it should not be below the largest attachment accepted
+	MaxSize int64 `json:"max_size" mapstructure:"max_size" yaml:"max_size"`
+}
+
+type S3Config struct {
+	Endpoint  string `json:"endpoint" mapstructure:"endpoint" yaml:"endpoint"`
+	Region    string `json:"region" mapstructure:"region" yaml:"region"`
+	Bucket    string `json:"bucket" mapstructure:"bucket" yaml:"bucket"`
+	AccessKey string `json:"access_key" mapstructure:"access_key" yaml:"access_key"`
+	SecretKey string `json:"secret_key" mapstructure:"secret_key" yaml:"secret_key"`
+	UseSSL    bool   `json:"use_ssl" mapstructure:"use_ssl" yaml:"use_ssl"`
+}
+
+// DefaultConfig return a default blob store config, a local directory unless S3 is selected
+func DefaultConfig() Config {
+	return Config{
+		Kind:      KindLocal,
+		URLExpiry: 15 * time.Minute,
+		Local: LocalConfig{
+			Directory: "attachments",
+			BaseURL:   "http://localhost:10080",
+			MaxSize:   25 << 20,
+		},
+		S3: S3Config{
+			Endpoint: "localhost:9000",
+			Region:   "us-east-1",
+			Bucket:   "attachments",
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package blobstore
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Inspection is what the content of a blob tells about it, the dimensions are only set for images
+// in a decodable format
+type Inspection struct {
+	Size     int64
+	Checksum string
+	MimeType string
+	Width    *int
+	Height   *int
+}
+
+// Inspect reads the content once to measure and hash it, sniff its MIME type and decode the
+// dimensions of images
+func Inspect(r io.Reader) (Inspection, error) {
+	hasher := &countingWriter{w: sha256.New()}
+	buffered := bufio.NewReader(r)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return Inspection{}, err
+	}
+
+	inspection := Inspection{MimeType: strings.TrimSpace(strings.Split(http.DetectContentType(head), ";")[0])}
+	content := io.TeeReader(buffered, hasher)
+	if strings.HasPrefix(inspection.MimeType, "image/") {
+		if config, _, err := image.DecodeConfig(content); err == nil {
+			inspection.Width, inspection.Height = &config.Width, &config.Height
+		}
+	}
+
+	// the bytes read while decoding went through the hash as well
+	if _, err := io.Copy(hasher, buffered); err != nil {
+		return Inspection{}, err
+	}
+	inspection.Size = hasher.n
+	inspection.Checksum = hex.EncodeToString(hasher.w.Sum(nil))
+	return inspection, nil
+}
+
+type countingWriter struct {
+	w hash.Hash
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
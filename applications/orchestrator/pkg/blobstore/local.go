@@ -0,0 +1,204 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+)
+
+type localStore struct {
+	cfg        LocalConfig
+	expiry     time.Duration
+	signingKey []byte
+}
+
+// NewLocalStore return a store keeping the blobs in the configured directory, its URLs are signed
+// with an HMAC of the method, key, expiry and upload size
+func NewLocalStore(cfg LocalConfig, expiry time.Duration) (BlobStore, error) {
+	if err := os.MkdirAll(cfg.Directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	signingKey := []byte(cfg.SigningKey)
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &localStore{
+		cfg:        cfg,
+		expiry:     expiry,
+		signingKey: signingKey,
+	}, nil
+}
+
+func (l *localStore) UploadURL(_ context.Context, key string, size int64) (SignedURL, error) {
+	return l.sign(http.MethodPut, key, strconv.FormatInt(size, 10))
+}
+
+func (l *localStore) DownloadURL(_ context.Context, key string) (SignedURL, error) {
+	return l.sign(http.MethodGet, key, "")
+}
+
+func (l *localStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (l *localStore) Move(_ context.Context, from string, to string) error {
+	fromPath, err := l.path(from)
+	if err != nil {
+		return err
+	}
+	toPath, err := l.path(to)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(fromPath, toPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ServeUpload stores the body of a signed PUT, an upload replaces the previous content of the blob
+func (l *localStore) ServeUpload(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	key := pathParams["key"]
+	if !l.verify(r, key) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+	path, err := l.path(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// written aside and renamed so that a failed upload leaves no partial blob
+	temp, err := os.CreateTemp(l.cfg.Directory, ".upload-*")
+	if err != nil {
+		l.fail(w, key, err)
+		return
+	}
+	defer os.Remove(temp.Name())
+
+	size, _ := strconv.ParseInt(r.URL.Query().Get("size"), 10, 64)
+	_, err = io.Copy(temp, http.MaxBytesReader(w, r.Body, min(size, l.cfg.MaxSize)))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "blob too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		l.fail(w, key, err)
+		return
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		l.fail(w, key, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ServeDownload serves the content of a signed GET as an opaque download, the declared type of an attachment
+// is not checked against its content and the blob is served from the origin of the API
+func (l *localStore) ServeDownload(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	key := pathParams["key"]
+	if !l.verify(r, key) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+	path, err := l.path(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, path)
+}
+
+func (l *localStore) fail(w http.ResponseWriter, key string, err error) {
+	logger.WithFields(logger.Fields{
+		"error": err,
+		"key":   key,
+	}).Errorf("Failed to store blob")
+	http.Error(w, "failed to store blob", http.StatusInternalServerError)
+}
+
+// sign return the URL of the operation, size is the limit of an upload and empty for a download
+func (l *localStore) sign(method string, key string, size string) (SignedURL, error) {
+	if _, err := l.path(key); err != nil {
+		return SignedURL{}, err
+	}
+
+	expiresAt := time.Now().Add(l.expiry)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   []string{expires},
+		"signature": []string{l.signature(method, key, expires, size)},
+	}
+	if size != "" {
+		query.Set("size", size)
+	}
+	return SignedURL{
+		URL:       strings.TrimSuffix(l.cfg.BaseURL, "/") + strings.Replace(BlobPattern, "{key}", url.PathEscape(key), 1) + "?" + query.Encode(),
+		Method:    method,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (l *localStore) verify(r *http.Request, key string) bool {
+	expires := r.URL.Query().Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+
+	expected := l.signature(r.Method, key, expires, r.URL.Query().Get("size"))
+	return hmac.Equal([]byte(expected), []byte(r.URL.Query().Get("signature")))
+}
+
+func (l *localStore) signature(method string, key string, expires string, size string) string {
+	mac := hmac.New(sha256.New, l.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires + "\n" + size))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path return the file of the blob, keys are single path elements
+func (l *localStore) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(l.cfg.Directory, key), nil
+}
@@ -0,0 +1,101 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type s3Store struct {
+	cfg    S3Config
+	expiry time.Duration
+	client *minio.Client
+}
+
+// NewS3Store return a store keeping the blobs in an S3 compatible bucket, created when missing,
+// its URLs are presigned by the client. Uploads are POST policies, a presigned PUT cannot bound the size
+func NewS3Store(ctx context.Context, cfg S3Config, expiry time.Duration) (BlobStore, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s3Store{
+		cfg:    cfg,
+		expiry: expiry,
+		client: client,
+	}, nil
+}
+
+func (s *s3Store) UploadURL(ctx context.Context, key string, size int64) (SignedURL, error) {
+	expiresAt := time.Now().Add(s.expiry)
+	policy := minio.NewPostPolicy()
+	for _, err := range []error{
+		policy.SetBucket(s.cfg.Bucket),
+		policy.SetKey(key),
+		policy.SetExpires(expiresAt),
+		policy.SetContentLengthRange(0, size),
+	} {
+		if err != nil {
+			return SignedURL{}, err
+		}
+	}
+
+	signed, fields, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return SignedURL{}, err
+	}
+	return SignedURL{URL: signed.String(), Method: http.MethodPost, Fields: fields, ExpiresAt: expiresAt}, nil
+}
+
+func (s *s3Store) DownloadURL(ctx context.Context, key string) (SignedURL, error) {
+	expiresAt := time.Now().Add(s.expiry)
+	signed, err := s.client.PresignedGetObject(ctx, s.cfg.Bucket, key, s.expiry, url.Values{})
+	if err != nil {
+		return SignedURL{}, err
+	}
+	return SignedURL{URL: signed.String(), Method: http.MethodGet, ExpiresAt: expiresAt}, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if _, err := s.client.StatObject(ctx, s.cfg.Bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+}
+
+// Move copies the object then removes the original, S3 has no rename
+func (s *s3Store) Move(ctx context.Context, from string, to string) error {
+	if _, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.cfg.Bucket, Object: to},
+		minio.CopySrcOptions{Bucket: s.cfg.Bucket, Object: from},
+	); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return ErrNotFound
+		}
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.cfg.Bucket, from, minio.RemoveObjectOptions{})
+}
@@ -10,6 +10,40 @@ const (
 	MessagesTopicPrefix = "messages"
 )
 
+const (
+	// ClientMessageIdMetadataKey carries the idempotency key of SendMessage, forwarded from the HTTP header of the same name
+	ClientMessageIdMetadataKey = "x-client-message-id"
+	// ResumeCursorMetadataKey carries the last message id a reconnecting client got, either a global id
+	// or conversation_id:message_id pairs, e.g. "120" or "7:118,9:120" or "120,7:118"
+	ResumeCursorMetadataKey = "x-resume-cursor"
+	// ReplayGapMetadataKey is a stream header telling the client it missed more than can be replayed, it
+	// reloads its conversations with ListMessages while the stream only carries the live messages
+	ReplayGapMetadataKey = "x-replay-gap"
+	// ParentMessageIdMetadataKey and QuotedMessageIdMetadataKey make SendMessage post a thread reply and quote
+	// another message of the conversation, forwarded from the HTTP headers of the same name
+	ParentMessageIdMetadataKey = "x-parent-message-id"
+	QuotedMessageIdMetadataKey = "x-quoted-message-id"
+	// AttachmentIdsMetadataKey carries the comma separated uploaded attachments sent with a message
+	AttachmentIdsMetadataKey = "x-attachment-ids"
+)
+
+const (
+	ConnectionsKeyPrefix = "connections"
+	ConnectionsNodesKey  = "connections.nodes"
+)
+
 func GenerateMessagesTopic(server string) string {
 	return fmt.Sprintf("%s.%s", MessagesTopicPrefix, server)
 }
+
+func GenerateUserConnectionsKey(user string) string {
+	return fmt.Sprintf("%s.user.%s", ConnectionsKeyPrefix, user)
+}
+
+func GenerateNodeLeaseKey(server string) string {
+	return fmt.Sprintf("%s.node.%s.lease", ConnectionsKeyPrefix, server)
+}
+
+func GenerateNodeUsersKey(server string) string {
+	return fmt.Sprintf("%s.node.%s.users", ConnectionsKeyPrefix, server)
+}
@@ -0,0 +1,40 @@
+package converter
+
+import (
+	"strconv"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"github.com/YumikoKawaii/shared/logger"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// ToApiMessage converts a stored message, the timestamp is the server creation time in milliseconds.
+// A deleted message keeps its id with an empty content, clients replace a message they already have
+// with a frame of the same id. Structured types carry their JSON payload as content. Thread replies
+// and quotes carry the ids they reference, see References. A stored type that cannot be parsed is
+// logged and sent as TEXT
+func ToApiMessage(message models.Message) *api.Message {
+	content := message.Body()
+	if message.IsDeleted() {
+		content = ""
+	}
+
+	messageType, err := messagetype.Parse(message.Type)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":      err,
+			"message_id": message.Id,
+		}).Errorf("Failed to parse message type")
+	}
+
+	return withReferences(&api.Message{
+		MessageId:      strconv.Itoa(message.Id),
+		Sender:         message.Sender,
+		ConversationId: message.ConversationId,
+		Content:        content,
+		Type:           messageType,
+		Timestamp:      message.CreatedAt.UnixMilli(),
+		Status:         api.MessageStatus_SENT,
+	}, message)
+}
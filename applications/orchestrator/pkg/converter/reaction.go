@@ -0,0 +1,34 @@
+package converter
+
+import (
+	"strconv"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// frame types outside of the proto enum, clients built on an older proto see them as unknown values
+const (
+	FrameTypeReactionAdded   api.MessageType = 100
+	FrameTypeReactionRemoved api.MessageType = 101
+)
+
+// ToApiReaction converts a reaction change to a frame, the message id is the reacted message, the sender
+// the member who reacted and the content the emoji
+func ToApiReaction(reaction models.MessageReaction, conversationId int64, added bool, at time.Time) *api.Message {
+	frameType := FrameTypeReactionRemoved
+	if added {
+		frameType = FrameTypeReactionAdded
+	}
+
+	return &api.Message{
+		MessageId:      strconv.Itoa(reaction.MessageId),
+		Sender:         reaction.UserIdentification,
+		ConversationId: conversationId,
+		Content:        reaction.Emoji,
+		Type:           frameType,
+		Timestamp:      at.UnixMilli(),
+		Status:         api.MessageStatus_SENT,
+	}
+}
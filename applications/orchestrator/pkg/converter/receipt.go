@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"strconv"
+	"time"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// ToApiReceipt converts a cursor of a member to a frame, the sender is the member and the message id the
+// last message delivered or read depending on the status
+func ToApiReceipt(userConversation models.UserConversation, status api.MessageStatus) *api.Message {
+	messageId, at := userConversation.LastDeliveredMessageId, userConversation.LastDeliveredAt
+	if status == api.MessageStatus_READ {
+		messageId, at = userConversation.LastReadMessageId, userConversation.LastReadAt
+	}
+
+	return &api.Message{
+		MessageId:      strconv.Itoa(messageId),
+		Sender:         userConversation.UserIdentification,
+		ConversationId: userConversation.ConversationId,
+		Timestamp:      unixMilli(at),
+		Status:         status,
+	}
+}
+
+func unixMilli(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
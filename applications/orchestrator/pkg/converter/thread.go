@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"strconv"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"google.golang.org/protobuf/encoding/protowire"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// frame fields outside of the proto, sent as unknown fields so that clients built on a proto declaring
+// them read them and older clients skip them
+const (
+	FieldParentMessageId protowire.Number = 100
+	FieldQuotedMessageId protowire.Number = 101
+)
+
+// withReferences flags a frame as a thread reply or a quote by appending the parent and quoted message ids
+func withReferences(frame *api.Message, message models.Message) *api.Message {
+	unknown := frame.ProtoReflect().GetUnknown()
+	unknown = appendId(unknown, FieldParentMessageId, message.ParentMessageId)
+	unknown = appendId(unknown, FieldQuotedMessageId, message.QuotedMessageId)
+	frame.ProtoReflect().SetUnknown(unknown)
+	return frame
+}
+
+func appendId(unknown []byte, number protowire.Number, id *int) []byte {
+	if id == nil {
+		return unknown
+	}
+	unknown = protowire.AppendTag(unknown, number, protowire.BytesType)
+	return protowire.AppendString(unknown, strconv.Itoa(*id))
+}
+
+// References return the parent and quoted message ids of a frame, empty when it has none
+func References(frame *api.Message) (parentMessageId string, quotedMessageId string) {
+	unknown := frame.ProtoReflect().GetUnknown()
+	for len(unknown) > 0 {
+		number, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return
+		}
+		unknown = unknown[n:]
+
+		if wireType != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(number, wireType, unknown)
+		} else {
+			var value string
+			value, n = protowire.ConsumeString(unknown)
+			switch number {
+			case FieldParentMessageId:
+				parentMessageId = value
+			case FieldQuotedMessageId:
+				quotedMessageId = value
+			}
+		}
+		if n < 0 {
+			return
+		}
+		unknown = unknown[n:]
+	}
+	return
+}
@@ -0,0 +1,21 @@
+package database
+
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// Config hold the database driver, MySQL is configured by mysql.Config
+type Config struct {
+	Driver string `json:"driver" mapstructure:"driver" yaml:"driver"`
+	// SQLitePath is the database file, it is created when missing
+	SQLitePath string `json:"sqlite_path" mapstructure:"sqlite_path" yaml:"sqlite_path"`
+}
+
+// DefaultConfig return a default database config, MySQL unless SQLite is selected
+func DefaultConfig() Config {
+	return Config{
+		Driver:     DriverMySQL,
+		SQLitePath: "orchestrator.db",
+	}
+}
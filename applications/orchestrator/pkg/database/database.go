@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/YumikoKawaii/shared/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// Open return the database of the configured driver. The MySQL schema is owned by the migrations
+// while a SQLite database is created from the models
+func Open(cfg Config, mysqlCfg *mysql.Config) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case DriverMySQL:
+		return mysql.Initialize(mysqlCfg), nil
+	case DriverSQLite:
+		return openSQLite(cfg.SQLitePath)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+func openSQLite(path string) (*gorm.DB, error) {
+	// WAL lets the streamer read while the relay holds a write transaction
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000", path)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	KindMessage = "message"
+	// KindReceipt is a read receipt, its payload is a message frame with the READ status
+	KindReceipt = "receipt"
+	// KindEdit and KindDelete carry the message as it is after the change, a deleted message has no content
+	KindEdit   = "edit"
+	KindDelete = "delete"
+	// KindReaction is a reaction added to or removed from a message
+	KindReaction = "reaction"
+)
+
+// Envelope is the payload published to the node topics, it carries the W3C trace context of the
+// publisher so that the streamer continues the same trace
+type Envelope struct {
+	Kind    string            `json:"kind"`
+	Trace   map[string]string `json:"trace,omitempty"`
+	Payload []byte            `json:"payload"`
+}
+
+// Wrap encodes the payload in an envelope holding the trace context of ctx
+func Wrap(ctx context.Context, kind string, payload []byte) ([]byte, error) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	return json.Marshal(Envelope{
+		Kind:    kind,
+		Trace:   carrier,
+		Payload: payload,
+	})
+}
+
+// Unwrap decodes an envelope and return ctx with the trace context it carries
+func Unwrap(ctx context.Context, bytes []byte) (context.Context, Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(bytes, &envelope); err != nil {
+		return ctx, envelope, err
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(envelope.Trace)), envelope, nil
+}
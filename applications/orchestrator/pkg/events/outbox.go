@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/golang/protobuf/proto"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+)
+
+// Enqueue stores an event for the members of the conversation in the outbox with the trace context of ctx,
+// the relay publishes it once the transaction commits
+func Enqueue(ctx context.Context, store uow.IStore, conversationId int64, kind string, event proto.Message) error {
+	eventBytes, err := proto.Marshal(event)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": conversationId,
+			"kind":            kind,
+		}).Errorf("Failed to marshal event")
+		return err
+	}
+
+	payload, err := Wrap(ctx, kind, eventBytes)
+	if err != nil {
+		return err
+	}
+
+	if _, err := store.Outbox().Save(ctx, &models.Outbox{
+		ConversationId: conversationId,
+		Payload:        payload,
+		Status:         models.OutboxStatusPending,
+		AvailableAt:    time.Now(),
+	}); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":           err,
+			"conversation_id": conversationId,
+			"kind":            kind,
+		}).Errorf("Failed to save outbox event")
+		return err
+	}
+	return nil
+}
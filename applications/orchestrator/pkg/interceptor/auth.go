@@ -0,0 +1,218 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/golang-jwt/jwt/v5"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationMetadataKey = "authorization"
+	bearerPrefix             = "bearer "
+	defaultIdentityClaim     = "sub"
+)
+
+// AuthConfig hold the key set accepted for bearer tokens, at least one key is required unless auth is disabled,
+// which only the all-in-one command accepts. E.g. in config.yaml
+//
+//	authcfg:
+//	  enabled: true
+//	  issuer: https://auth.example.com
+//	  keys:
+//	    - id: main
+//	      algorithm: RS256
+//	      public_key: |
+//	        -----BEGIN PUBLIC KEY-----
+//	        ...
+//	        -----END PUBLIC KEY-----
+//	    - id: legacy
+//	      algorithm: HS256
+//	      secret: change-me
+type AuthConfig struct {
+	Enabled bool        `json:"enabled" mapstructure:"enabled" yaml:"enabled"`
+	Keys    []KeyConfig `json:"keys" mapstructure:"keys" yaml:"keys"`
+	// Issuer and Audience are checked when set
+	Issuer   string `json:"issuer" mapstructure:"issuer" yaml:"issuer"`
+	Audience string `json:"audience" mapstructure:"audience" yaml:"audience"`
+	// IdentityClaim is the claim holding the user identification, sub by default
+	IdentityClaim string `json:"identity_claim" mapstructure:"identity_claim" yaml:"identity_claim"`
+}
+
+// KeyConfig is a verification key, Secret is used by HS256 and PublicKey (PEM) by RS256 and EdDSA
+type KeyConfig struct {
+	Id        string `json:"id" mapstructure:"id" yaml:"id"`
+	Algorithm string `json:"algorithm" mapstructure:"algorithm" yaml:"algorithm"`
+	Secret    string `json:"secret" mapstructure:"secret" yaml:"secret"`
+	PublicKey string `json:"public_key" mapstructure:"public_key" yaml:"public_key"`
+}
+
+// DefaultAuthConfig return a default auth config, keys have to be provided
+func DefaultAuthConfig() AuthConfig {
+	return AuthConfig{
+		Enabled:       true,
+		Keys:          make([]KeyConfig, 0),
+		IdentityClaim: defaultIdentityClaim,
+	}
+}
+
+type Authenticator interface {
+	Unary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+// NewAuthenticator return an authenticator verifying bearer tokens against the configured keys, a disabled
+// config is refused, see NewNoopAuthenticator
+func NewAuthenticator(cfg AuthConfig) (Authenticator, error) {
+	if !cfg.Enabled {
+		return nil, errors.New("auth is disabled, only the all-in-one command runs without it")
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("auth is enabled but no key is configured")
+	}
+
+	keys := make(map[string]verificationKey, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		key, err := parseKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", keyCfg.Id, err)
+		}
+		keys[keyCfg.Id] = key
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	identityClaim := cfg.IdentityClaim
+	if identityClaim == "" {
+		identityClaim = defaultIdentityClaim
+	}
+
+	return &jwtAuthenticator{
+		keys:          keys,
+		parser:        jwt.NewParser(options...),
+		identityClaim: identityClaim,
+	}, nil
+}
+
+type verificationKey struct {
+	algorithm string
+	key       interface{}
+}
+
+func parseKey(cfg KeyConfig) (verificationKey, error) {
+	switch cfg.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if cfg.Secret == "" {
+			return verificationKey{}, errors.New("secret is required")
+		}
+		return verificationKey{algorithm: cfg.Algorithm, key: []byte(cfg.Secret)}, nil
+	case jwt.SigningMethodRS256.Alg():
+		key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(cfg.PublicKey))
+		return verificationKey{algorithm: cfg.Algorithm, key: key}, err
+	case jwt.SigningMethodEdDSA.Alg():
+		key, err := jwt.ParseEdPublicKeyFromPEM([]byte(cfg.PublicKey))
+		return verificationKey{algorithm: cfg.Algorithm, key: key}, err
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+}
+
+type jwtAuthenticator struct {
+	keys          map[string]verificationKey
+	parser        *jwt.Parser
+	identityClaim string
+}
+
+func (a *jwtAuthenticator) Unary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, request)
+}
+
+func (a *jwtAuthenticator) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	wrapped := middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
+
+func (a *jwtAuthenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, authorizationMetadataKey)
+	if len(values) == 0 || !strings.HasPrefix(strings.ToLower(values[0]), bearerPrefix) {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(values[0][len(bearerPrefix):], claims, a.keyFunc); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":  err,
+			"method": method,
+		}).Warnf("Rejected bearer token")
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+
+	userIdentification, ok := claims[a.identityClaim].(string)
+	if !ok || userIdentification == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "token has no %s claim", a.identityClaim)
+	}
+
+	return ContextWithUserIdentification(ctx, userIdentification), nil
+}
+
+// keyFunc picks the key named by the kid header, a token without kid is accepted when a single key is configured
+func (a *jwtAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.keys[kid]
+	if !ok && kid == "" && len(a.keys) == 1 {
+		for _, only := range a.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != key.algorithm {
+		return nil, fmt.Errorf("key %q does not accept %s", kid, token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+// NewNoopAuthenticator return an authenticator letting every call through, callers are trusted with the user
+// identification they send. It is meant for local runs only
+func NewNoopAuthenticator() Authenticator {
+	logger.Warnf("AUTHENTICATION IS DISABLED: callers are trusted with the user identification they send")
+	return &noopAuthenticator{}
+}
+
+type noopAuthenticator struct{}
+
+func (a *noopAuthenticator) Unary(ctx context.Context, request interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(ctx, request)
+}
+
+func (a *noopAuthenticator) Stream(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, stream)
+}
@@ -0,0 +1,219 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/metadata"
+)
+
+const testSecret = "test-secret"
+
+func rsaKeys(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newTestAuthenticator(t *testing.T, cfg AuthConfig) *jwtAuthenticator {
+	t.Helper()
+	authenticator, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator.(*jwtAuthenticator)
+}
+
+func TestAuthenticate(t *testing.T) {
+	private, publicPEM := rsaKeys(t)
+	cfg := DefaultAuthConfig()
+	cfg.Issuer = "https://auth.example.com"
+	cfg.Audience = "orchestrator"
+	cfg.Keys = []KeyConfig{
+		{Id: "hmac", Algorithm: jwt.SigningMethodHS256.Alg(), Secret: testSecret},
+		{Id: "rsa", Algorithm: jwt.SigningMethodRS256.Alg(), PublicKey: publicPEM},
+	}
+	authenticator := newTestAuthenticator(t, cfg)
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		result := jwt.MapClaims{
+			"sub": "alice",
+			"iss": cfg.Issuer,
+			"aud": cfg.Audience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for key, value := range overrides {
+			if value == nil {
+				delete(result, key)
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "hmac key",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(nil)),
+			want:  "alice",
+		},
+		{
+			name:  "rsa key and lowercase scheme",
+			token: "bearer " + sign(t, jwt.SigningMethodRS256, private, "rsa", claims(nil)),
+			want:  "alice",
+		},
+		{
+			name:  "alg differs from the key",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(publicPEM), "rsa", claims(nil)),
+		},
+		{
+			name:  "unknown kid",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "other", claims(nil)),
+		},
+		{
+			name:  "no kid with several keys",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+		},
+		{
+			name:  "wrong secret",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "hmac", claims(nil)),
+		},
+		{
+			name:  "unsigned token",
+			token: "Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "hmac", claims(nil)),
+		},
+		{
+			name:  "missing exp",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"exp": nil})),
+		},
+		{
+			name:  "expired",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+		},
+		{
+			name:  "issuer mismatch",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+		},
+		{
+			name:  "audience mismatch",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"aud": "other"})),
+		},
+		{
+			name:  "missing identity claim",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"sub": nil})),
+		},
+		{
+			name:  "empty identity claim",
+			token: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac", claims(jwt.MapClaims{"sub": ""})),
+		},
+		{
+			name:  "not a bearer token",
+			token: "Basic YWxpY2U6c2VjcmV0",
+		},
+		{
+			name: "no authorization",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if test.token != "" {
+				md.Set(authorizationMetadataKey, test.token)
+			}
+
+			ctx, err := authenticator.authenticate(metadata.NewIncomingContext(context.Background(), md), "/test")
+			if test.want == "" {
+				if err == nil {
+					t.Fatal("authenticate() accepted the token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate() = %s", err)
+			}
+			if got, _ := UserIdentificationFromContext(ctx); got != test.want {
+				t.Fatalf("user identification = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateSingleKeyAndIdentityClaim(t *testing.T) {
+	cfg := DefaultAuthConfig()
+	cfg.IdentityClaim = "user_id"
+	cfg.Keys = []KeyConfig{{Id: "hmac", Algorithm: jwt.SigningMethodHS256.Alg(), Secret: testSecret}}
+	authenticator := newTestAuthenticator(t, cfg)
+
+	token := sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+		"sub":     "alice",
+		"user_id": "bob",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	md := metadata.Pairs(authorizationMetadataKey, "Bearer "+token)
+	ctx, err := authenticator.authenticate(metadata.NewIncomingContext(context.Background(), md), "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := UserIdentificationFromContext(ctx); got != "bob" {
+		t.Fatalf("user identification = %q, want the user_id claim", got)
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(cfg *AuthConfig)
+	}{
+		{name: "disabled", cfg: func(cfg *AuthConfig) { cfg.Enabled = false }},
+		{name: "no key", cfg: func(cfg *AuthConfig) {}},
+		{name: "unsupported algorithm", cfg: func(cfg *AuthConfig) {
+			cfg.Keys = []KeyConfig{{Id: "main", Algorithm: "HS512", Secret: testSecret}}
+		}},
+		{name: "hmac without secret", cfg: func(cfg *AuthConfig) {
+			cfg.Keys = []KeyConfig{{Id: "main", Algorithm: "HS256"}}
+		}},
+		{name: "invalid public key", cfg: func(cfg *AuthConfig) {
+			cfg.Keys = []KeyConfig{{Id: "main", Algorithm: "RS256", PublicKey: "not a key"}}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := DefaultAuthConfig()
+			test.cfg(&cfg)
+			if _, err := NewAuthenticator(cfg); err == nil {
+				t.Fatal("NewAuthenticator() accepted the config")
+			}
+		})
+	}
+}
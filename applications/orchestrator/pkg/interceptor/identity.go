@@ -0,0 +1,36 @@
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userIdentificationKey struct{}
+
+func ContextWithUserIdentification(ctx context.Context, userIdentification string) context.Context {
+	return context.WithValue(ctx, userIdentificationKey{}, userIdentification)
+}
+
+// UserIdentificationFromContext return the user verified by the authenticator
+func UserIdentificationFromContext(ctx context.Context) (string, bool) {
+	userIdentification, ok := ctx.Value(userIdentificationKey{}).(string)
+	return userIdentification, ok
+}
+
+// Identify return the user acting in the call. The verified identity wins over the one claimed by the
+// client, which is only trusted when authentication is disabled.
+func Identify(ctx context.Context, claimed string) (string, error) {
+	if userIdentification, ok := UserIdentificationFromContext(ctx); ok {
+		if claimed != "" && claimed != userIdentification {
+			return "", status.Error(codes.PermissionDenied, "cannot act on behalf of another user")
+		}
+		return userIdentification, nil
+	}
+
+	if claimed == "" {
+		return "", status.Error(codes.Unauthenticated, "user identification is required")
+	}
+	return claimed, nil
+}
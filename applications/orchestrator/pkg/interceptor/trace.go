@@ -42,6 +42,7 @@ func (c *metadataCarrier) Keys() []string {
 
 type Tracer interface {
 	Unary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
 }
 
 func NewTracer(tracer trace.Tracer) Tracer {
@@ -76,8 +77,43 @@ func (i *tracerImpl) Unary(ctx context.Context, request interface{}, info *grpc.
 	logger.Infof("Handling request - TraceID: %s, SpanID: %s, Method: %s", traceID, spanID, info.FullMethod)
 
 	resp, err := handler(ctx, request)
+	recordStatus(span, err)
 
-	// Record status
+	return resp, err
+}
+
+// Stream records a span for the whole lifetime of the stream, with an event per message sent or received
+func (i *tracerImpl) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := stream.Context()
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, &metadataCarrier{md: md})
+	}
+
+	ctx, span := i.tracer.Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+	)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.RPCSystemGRPC,
+		semconv.RPCService(info.FullMethod),
+	)
+
+	spanCtx := span.SpanContext()
+	logger.Infof("Handling stream - TraceID: %s, SpanID: %s, Method: %s", spanCtx.TraceID().String(), spanCtx.SpanID().String(), info.FullMethod)
+
+	err := handler(srv, &tracedServerStream{
+		ServerStream: stream,
+		ctx:          ctx,
+		span:         span,
+	})
+	recordStatus(span, err)
+
+	return err
+}
+
+func recordStatus(span trace.Span, err error) {
 	if err != nil {
 		span.RecordError(err)
 		s, _ := status.FromError(err)
@@ -87,6 +123,35 @@ func (i *tracerImpl) Unary(ctx context.Context, request interface{}, info *grpc.
 		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(grpc_codes.OK)))
 		span.SetStatus(codes.Ok, "")
 	}
+}
 
-	return resp, err
+// tracedServerStream carries the span in the stream context and adds an event per message
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	span     trace.Span
+	sent     int
+	received int
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracedServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+		s.span.AddEvent("message", trace.WithAttributes(semconv.MessageTypeSent, semconv.MessageID(s.sent)))
+	}
+	return err
+}
+
+func (s *tracedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		s.span.AddEvent("message", trace.WithAttributes(semconv.MessageTypeReceived, semconv.MessageID(s.received)))
+	}
+	return err
 }
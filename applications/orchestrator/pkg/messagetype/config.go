@@ -0,0 +1,15 @@
+package messagetype
+
+// Config hold the limits of message contents, lengths are in characters
+type Config struct {
+	MaxTextLength    int `json:"max_text_length" mapstructure:"max_text_length" yaml:"max_text_length"`
+	MaxCaptionLength int `json:"max_caption_length" mapstructure:"max_caption_length" yaml:"max_caption_length"`
+}
+
+// DefaultConfig return the default content limits
+func DefaultConfig() Config {
+	return Config{
+		MaxTextLength:    4000,
+		MaxCaptionLength: 1000,
+	}
+}
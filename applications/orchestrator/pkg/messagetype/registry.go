@@ -0,0 +1,77 @@
+package messagetype
+
+import (
+	"sync"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+// Input is a message content as sent, with the attachments it references
+type Input struct {
+	Content     string
+	Attachments []models.Attachment
+}
+
+// Output is the content to store, Payload holds the normalized JSON of structured types and Content
+// a plain text summary of it
+type Output struct {
+	Content string
+	Payload *string
+}
+
+// Handler validates and normalizes the contents of a message type, the errors it return are
+// reported to the sender as is
+type Handler interface {
+	Normalize(input Input) (Output, error)
+}
+
+// Registry holds the handler of every accepted message type
+type Registry interface {
+	Register(messageType api.MessageType, handler Handler)
+	// Normalize return the content to store or an InvalidArgument error
+	Normalize(messageType api.MessageType, input Input) (Output, error)
+}
+
+type registry struct {
+	mu       sync.RWMutex
+	handlers map[api.MessageType]Handler
+}
+
+// NewRegistry return a registry holding the handlers of the built-in types
+func NewRegistry(cfg Config) Registry {
+	r := &registry{handlers: make(map[api.MessageType]Handler)}
+	r.Register(api.MessageType_TEXT, &textHandler{maxLength: cfg.MaxTextLength})
+	r.Register(api.MessageType_IMAGE, &mediaHandler{mimePrefix: "image/", maxCaptionLength: cfg.MaxCaptionLength})
+	r.Register(api.MessageType_VIDEO, &mediaHandler{mimePrefix: "video/", maxCaptionLength: cfg.MaxCaptionLength})
+	r.Register(api.MessageType_AUDIO, &mediaHandler{mimePrefix: "audio/", maxCaptionLength: cfg.MaxCaptionLength})
+	r.Register(api.MessageType_FILE, &mediaHandler{maxCaptionLength: cfg.MaxCaptionLength})
+	r.Register(api.MessageType_LOCATION, structured(normalizeLocation))
+	r.Register(Contact, structured(normalizeContact))
+	r.Register(Sticker, structured(normalizeSticker))
+	return r
+}
+
+func (r *registry) Register(messageType api.MessageType, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[messageType] = handler
+}
+
+func (r *registry) Normalize(messageType api.MessageType, input Input) (Output, error) {
+	r.mu.RLock()
+	handler, ok := r.handlers[messageType]
+	r.mu.RUnlock()
+	if !ok {
+		return Output{}, status.Errorf(codes.InvalidArgument, "unsupported message type %s", Name(messageType))
+	}
+
+	output, err := handler.Normalize(input)
+	if err != nil {
+		return Output{}, status.Errorf(codes.InvalidArgument, "invalid %s message: %s", Name(messageType), err.Error())
+	}
+	return output, nil
+}
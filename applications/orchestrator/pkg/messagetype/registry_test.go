@@ -0,0 +1,97 @@
+package messagetype
+
+import (
+	"testing"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegistryNormalize(t *testing.T) {
+	registry := NewRegistry(DefaultConfig())
+	tests := []struct {
+		name        string
+		messageType api.MessageType
+		content     string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "text",
+			messageType: api.MessageType_TEXT,
+			content:     "hello",
+			want:        "hello",
+		},
+		{
+			name:        "contact",
+			messageType: Contact,
+			content:     `{"name": "Alice", "phone": "+81 3 1234 5678"}`,
+			want:        "Alice",
+		},
+		{
+			name:        "invalid content",
+			messageType: api.MessageType_TEXT,
+			content:     "",
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported type",
+			messageType: api.MessageType(99),
+			content:     "hello",
+			wantErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := registry.Normalize(test.messageType, Input{Content: test.content})
+			if test.wantErr {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Normalize() = %+v, %v, want an InvalidArgument error", output, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize() = %s", err)
+			}
+			if output.Content != test.want {
+				t.Fatalf("content = %q, want %q", output.Content, test.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    api.MessageType
+		wantErr bool
+	}{
+		{name: "TEXT", want: api.MessageType_TEXT},
+		{name: "LOCATION", want: api.MessageType_LOCATION},
+		{name: "CONTACT", want: Contact},
+		{name: "STICKER", want: Sticker},
+		{name: "42", want: api.MessageType(42)},
+		{name: "POLL", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.name)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Parse() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() = %s", err)
+			}
+			if got != test.want {
+				t.Fatalf("Parse() = %s, want %s", got, test.want)
+			}
+			if test.want != api.MessageType(42) && Name(got) != test.name {
+				t.Fatalf("Name() = %s, want %s", Name(got), test.name)
+			}
+		})
+	}
+}
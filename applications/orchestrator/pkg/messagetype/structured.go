@@ -0,0 +1,132 @@
+package messagetype
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"unicode/utf8"
+)
+
+const maxFieldLength = 255
+
+// structuredHandler accepts a JSON object decoded into T, unknown fields are rejected and the stored
+// payload is T encoded again
+type structuredHandler[T any] struct {
+	normalize func(value *T) (string, error)
+}
+
+func structured[T any](normalize func(value *T) (string, error)) Handler {
+	return &structuredHandler[T]{normalize: normalize}
+}
+
+func (h *structuredHandler[T]) Normalize(input Input) (Output, error) {
+	if len(input.Attachments) > 0 {
+		return Output{}, errors.New("structured messages cannot hold attachments")
+	}
+
+	var value T
+	decoder := json.NewDecoder(bytes.NewReader([]byte(input.Content)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&value); err != nil {
+		return Output{}, fmt.Errorf("content must be a JSON object: %s", err.Error())
+	}
+	if decoder.More() {
+		return Output{}, errors.New("content must be a single JSON object")
+	}
+
+	summary, err := h.normalize(&value)
+	if err != nil {
+		return Output{}, err
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return Output{}, err
+	}
+	encoded := string(payload)
+	return Output{Content: summary, Payload: &encoded}, nil
+}
+
+type Location struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Name      string   `json:"name,omitempty"`
+	Address   string   `json:"address,omitempty"`
+}
+
+func normalizeLocation(location *Location) (string, error) {
+	if location.Latitude == nil || *location.Latitude < -90 || *location.Latitude > 90 {
+		return "", errors.New("latitude must be between -90 and 90")
+	}
+	if location.Longitude == nil || *location.Longitude < -180 || *location.Longitude > 180 {
+		return "", errors.New("longitude must be between -180 and 180")
+	}
+	if err := checkFields(map[string]string{"name": location.Name, "address": location.Address}); err != nil {
+		return "", err
+	}
+
+	if location.Name != "" {
+		return location.Name, nil
+	}
+	return strconv.FormatFloat(*location.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(*location.Longitude, 'f', -1, 64), nil
+}
+
+type ContactCard struct {
+	Name               string `json:"name"`
+	Phone              string `json:"phone,omitempty"`
+	Email              string `json:"email,omitempty"`
+	UserIdentification string `json:"user_identification,omitempty"`
+}
+
+func normalizeContact(contact *ContactCard) (string, error) {
+	if contact.Name == "" {
+		return "", errors.New("name is required")
+	}
+	if contact.Phone == "" && contact.Email == "" && contact.UserIdentification == "" {
+		return "", errors.New("one of phone, email or user_identification is required")
+	}
+	if err := checkFields(map[string]string{
+		"name":                contact.Name,
+		"phone":               contact.Phone,
+		"email":               contact.Email,
+		"user_identification": contact.UserIdentification,
+	}); err != nil {
+		return "", err
+	}
+	if contact.Email != "" {
+		address, err := mail.ParseAddress(contact.Email)
+		if err != nil {
+			return "", errors.New("email must be a valid address")
+		}
+		contact.Email = address.Address
+	}
+	return contact.Name, nil
+}
+
+type StickerRef struct {
+	PackId    string `json:"pack_id"`
+	StickerId string `json:"sticker_id"`
+	Emoji     string `json:"emoji,omitempty"`
+}
+
+func normalizeSticker(sticker *StickerRef) (string, error) {
+	if sticker.PackId == "" || sticker.StickerId == "" {
+		return "", errors.New("pack_id and sticker_id are required")
+	}
+	if err := checkFields(map[string]string{"pack_id": sticker.PackId, "sticker_id": sticker.StickerId, "emoji": sticker.Emoji}); err != nil {
+		return "", err
+	}
+	return sticker.Emoji, nil
+}
+
+func checkFields(fields map[string]string) error {
+	for name, value := range fields {
+		if utf8.RuneCountInString(value) > maxFieldLength {
+			return fmt.Errorf("%s is longer than %d characters", name, maxFieldLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package messagetype
+
+import (
+	"strings"
+	"testing"
+
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+func TestStructuredHandlers(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     Handler
+		content     string
+		wantContent string
+		wantPayload string
+		wantErr     bool
+	}{
+		{
+			name:        "location with name",
+			handler:     structured(normalizeLocation),
+			content:     `{"latitude": 35.68, "longitude": 139.76, "name": "Tokyo"}`,
+			wantContent: "Tokyo",
+			wantPayload: `{"latitude":35.68,"longitude":139.76,"name":"Tokyo"}`,
+		},
+		{
+			name:        "location without name",
+			handler:     structured(normalizeLocation),
+			content:     `{"latitude": -33.5, "longitude": 0}`,
+			wantContent: "-33.5,0",
+			wantPayload: `{"latitude":-33.5,"longitude":0}`,
+		},
+		{
+			name:    "location without latitude",
+			handler: structured(normalizeLocation),
+			content: `{"longitude": 139.76}`,
+			wantErr: true,
+		},
+		{
+			name:    "latitude out of range",
+			handler: structured(normalizeLocation),
+			content: `{"latitude": 90.1, "longitude": 0}`,
+			wantErr: true,
+		},
+		{
+			name:    "longitude out of range",
+			handler: structured(normalizeLocation),
+			content: `{"latitude": 0, "longitude": -180.1}`,
+			wantErr: true,
+		},
+		{
+			name:    "location name too long",
+			handler: structured(normalizeLocation),
+			content: `{"latitude": 0, "longitude": 0, "name": "` + strings.Repeat("a", maxFieldLength+1) + `"}`,
+			wantErr: true,
+		},
+		{
+			name:        "contact email is normalized",
+			handler:     structured(normalizeContact),
+			content:     `{"name": "Alice", "email": "Alice <alice@example.com>"}`,
+			wantContent: "Alice",
+			wantPayload: `{"name":"Alice","email":"alice@example.com"}`,
+		},
+		{
+			name:        "contact with user identification",
+			handler:     structured(normalizeContact),
+			content:     `{"name": "Bob", "user_identification": "bob"}`,
+			wantContent: "Bob",
+			wantPayload: `{"name":"Bob","user_identification":"bob"}`,
+		},
+		{
+			name:    "contact without name",
+			handler: structured(normalizeContact),
+			content: `{"phone": "+81 3 1234 5678"}`,
+			wantErr: true,
+		},
+		{
+			name:    "contact without a way to reach",
+			handler: structured(normalizeContact),
+			content: `{"name": "Alice"}`,
+			wantErr: true,
+		},
+		{
+			name:    "contact with invalid email",
+			handler: structured(normalizeContact),
+			content: `{"name": "Alice", "email": "not an address"}`,
+			wantErr: true,
+		},
+		{
+			name:        "sticker",
+			handler:     structured(normalizeSticker),
+			content:     `{"pack_id": "cats", "sticker_id": "12", "emoji": "🐱"}`,
+			wantContent: "🐱",
+			wantPayload: `{"pack_id":"cats","sticker_id":"12","emoji":"🐱"}`,
+		},
+		{
+			name:    "sticker without id",
+			handler: structured(normalizeSticker),
+			content: `{"pack_id": "cats"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			handler: structured(normalizeSticker),
+			content: `{"pack_id": "cats", "sticker_id": "12", "url": "https://example.com"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not a JSON object",
+			handler: structured(normalizeSticker),
+			content: `cats/12`,
+			wantErr: true,
+		},
+		{
+			name:    "several JSON objects",
+			handler: structured(normalizeSticker),
+			content: `{"pack_id": "cats", "sticker_id": "12"} {}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := test.handler.Normalize(Input{Content: test.content})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Normalize() = %+v, want an error", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize() = %s", err)
+			}
+			if output.Content != test.wantContent {
+				t.Fatalf("content = %q, want %q", output.Content, test.wantContent)
+			}
+			if output.Payload == nil || *output.Payload != test.wantPayload {
+				t.Fatalf("payload = %v, want %s", output.Payload, test.wantPayload)
+			}
+		})
+	}
+}
+
+func TestStructuredHandlerRejectsAttachments(t *testing.T) {
+	input := Input{
+		Content:     `{"pack_id": "cats", "sticker_id": "12"}`,
+		Attachments: []models.Attachment{uploaded(1, "image/png")},
+	}
+	if output, err := structured(normalizeSticker).Normalize(input); err == nil {
+		t.Fatalf("Normalize() = %+v, want an error", output)
+	}
+}
@@ -0,0 +1,83 @@
+package messagetype
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+type textHandler struct {
+	maxLength int
+}
+
+func (h *textHandler) Normalize(input Input) (Output, error) {
+	if len(input.Attachments) > 0 {
+		return Output{}, errors.New("text messages cannot hold attachments")
+	}
+
+	content, err := normalizeText(input.Content, h.maxLength)
+	if err != nil {
+		return Output{}, err
+	}
+	if content == "" {
+		return Output{}, errors.New("content is required")
+	}
+	return Output{Content: content}, nil
+}
+
+// mediaHandler accepts messages made of attachments with an optional caption, the attachments
+// must have a MIME type starting with mimePrefix when it is set
+type mediaHandler struct {
+	mimePrefix       string
+	maxCaptionLength int
+}
+
+func (h *mediaHandler) Normalize(input Input) (Output, error) {
+	if len(input.Attachments) == 0 {
+		return Output{}, errors.New("at least one attachment is required")
+	}
+	for _, attachment := range input.Attachments {
+		if err := h.check(attachment); err != nil {
+			return Output{}, err
+		}
+	}
+
+	caption, err := normalizeText(input.Content, h.maxCaptionLength)
+	if err != nil {
+		return Output{}, err
+	}
+	return Output{Content: caption}, nil
+}
+
+func (h *mediaHandler) check(attachment models.Attachment) error {
+	if !attachment.IsUploaded() {
+		return fmt.Errorf("attachment %d is not uploaded", attachment.Id)
+	}
+	if !strings.HasPrefix(attachment.MimeType, h.mimePrefix) {
+		return fmt.Errorf("attachment %d is %s, %s* is expected", attachment.Id, attachment.MimeType, h.mimePrefix)
+	}
+	return nil
+}
+
+// normalizeText checks the encoding and length of a text, line endings are normalized to \n and
+// surrounding spaces trimmed
+func normalizeText(text string, maxLength int) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", errors.New("content must be valid UTF-8")
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return "", fmt.Errorf("content must not hold the control character %U", r)
+		}
+	}
+	if length := utf8.RuneCountInString(text); length > maxLength {
+		return "", fmt.Errorf("content is %d characters long, at most %d are accepted", length, maxLength)
+	}
+	return text, nil
+}
@@ -0,0 +1,204 @@
+package messagetype
+
+import (
+	"strings"
+	"testing"
+
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain text",
+			text: "hello",
+			want: "hello",
+		},
+		{
+			name: "surrounding spaces are trimmed",
+			text: " \n\thello\t\n ",
+			want: "hello",
+		},
+		{
+			name: "line endings are normalized",
+			text: "a\r\nb\r\nc",
+			want: "a\nb\nc",
+		},
+		{
+			name: "tabs and new lines are kept inside",
+			text: "a\tb\nc",
+			want: "a\tb\nc",
+		},
+		{
+			name: "length is counted in characters",
+			text: strings.Repeat("é", 10),
+			want: strings.Repeat("é", 10),
+		},
+		{
+			name:    "too long",
+			text:    strings.Repeat("a", 11),
+			wantErr: true,
+		},
+		{
+			name:    "invalid UTF-8",
+			text:    "a\xffb",
+			wantErr: true,
+		},
+		{
+			name:    "control character",
+			text:    "a\x00b",
+			wantErr: true,
+		},
+		{
+			name:    "lone carriage return",
+			text:    "a\rb",
+			wantErr: true,
+		},
+		{
+			name: "only spaces",
+			text: "  \n ",
+			want: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := normalizeText(test.text, 10)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("normalizeText() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeText() = %s", err)
+			}
+			if got != test.want {
+				t.Fatalf("normalizeText() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTextHandler(t *testing.T) {
+	handler := &textHandler{maxLength: 10}
+	tests := []struct {
+		name    string
+		input   Input
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "text",
+			input: Input{Content: " hello "},
+			want:  "hello",
+		},
+		{
+			name:    "empty",
+			input:   Input{Content: " \n "},
+			wantErr: true,
+		},
+		{
+			name:    "with attachments",
+			input:   Input{Content: "hello", Attachments: []models.Attachment{uploaded(1, "image/png")}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := handler.Normalize(test.input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Normalize() = %+v, want an error", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize() = %s", err)
+			}
+			if output.Content != test.want || output.Payload != nil {
+				t.Fatalf("Normalize() = %+v, want the content %q without payload", output, test.want)
+			}
+		})
+	}
+}
+
+func TestMediaHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler *mediaHandler
+		input   Input
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "image with caption",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input:   Input{Content: " look ", Attachments: []models.Attachment{uploaded(1, "image/png"), uploaded(2, "image/jpeg")}},
+			want:    "look",
+		},
+		{
+			name:    "caption is optional",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input:   Input{Attachments: []models.Attachment{uploaded(1, "image/png")}},
+			want:    "",
+		},
+		{
+			name:    "file accepts any type",
+			handler: &mediaHandler{maxCaptionLength: 10},
+			input:   Input{Attachments: []models.Attachment{uploaded(1, "application/pdf")}},
+			want:    "",
+		},
+		{
+			name:    "no attachment",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input:   Input{Content: "look"},
+			wantErr: true,
+		},
+		{
+			name:    "attachment not uploaded",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input: Input{Attachments: []models.Attachment{
+				{Id: 1, MimeType: "image/png", Status: models.AttachmentStatusPending},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "type differs from the message",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input:   Input{Attachments: []models.Attachment{uploaded(1, "image/png"), uploaded(2, "video/mp4")}},
+			wantErr: true,
+		},
+		{
+			name:    "caption too long",
+			handler: &mediaHandler{mimePrefix: "image/", maxCaptionLength: 10},
+			input:   Input{Content: strings.Repeat("a", 11), Attachments: []models.Attachment{uploaded(1, "image/png")}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := test.handler.Normalize(test.input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Normalize() = %+v, want an error", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize() = %s", err)
+			}
+			if output.Content != test.want || output.Payload != nil {
+				t.Fatalf("Normalize() = %+v, want the caption %q without payload", output, test.want)
+			}
+		})
+	}
+}
+
+func uploaded(id int, mimeType string) models.Attachment {
+	return models.Attachment{Id: id, MimeType: mimeType, Status: models.AttachmentStatusUploaded}
+}
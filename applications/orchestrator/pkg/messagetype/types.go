@@ -0,0 +1,44 @@
+package messagetype
+
+import (
+	"fmt"
+	"strconv"
+
+	api "github.com/YumikoKawaii/rpc.com/protobuf/orchestrator"
+)
+
+// message types outside of the proto enum, clients built on it send their numeric value. They belong in the
+// enum of rpc.com, until then 6 and 7 must not be taken by an upstream addition
+const (
+	Contact api.MessageType = 6
+	Sticker api.MessageType = 7
+)
+
+var extraNames = map[api.MessageType]string{
+	Contact: "CONTACT",
+	Sticker: "STICKER",
+}
+
+// Name return the name a message type is stored under
+func Name(messageType api.MessageType) string {
+	if name, ok := extraNames[messageType]; ok {
+		return name
+	}
+	return messageType.String()
+}
+
+// Parse return the message type stored under name, a type without a name is stored as its number
+func Parse(name string) (api.MessageType, error) {
+	if value, ok := api.MessageType_value[name]; ok {
+		return api.MessageType(value), nil
+	}
+	for messageType, extraName := range extraNames {
+		if extraName == name {
+			return messageType, nil
+		}
+	}
+	if value, err := strconv.ParseInt(name, 10, 32); err == nil {
+		return api.MessageType(value), nil
+	}
+	return api.MessageType_TEXT, fmt.Errorf("unknown message type %q", name)
+}
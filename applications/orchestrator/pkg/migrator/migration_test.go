@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "empty",
+			content: "\n-- nothing to do\n",
+			want:    []string{},
+		},
+		{
+			name:    "single line statements",
+			content: "DROP TABLE a;\nDROP TABLE b;\n",
+			want:    []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "multi line alter",
+			content: `ALTER TABLE messages
+    ADD COLUMN payload JSON NULL,
+    ADD INDEX idx_sender (sender);
+`,
+			want: []string{"ALTER TABLE messages\n    ADD COLUMN payload JSON NULL,\n    ADD INDEX idx_sender (sender)"},
+		},
+		{
+			name: "comment lines are dropped",
+			content: `-- the payload of structured messages
+ALTER TABLE messages
+    -- nullable for text messages
+    ADD COLUMN payload JSON NULL;
+  -- indented comment
+CREATE INDEX idx_a ON a (b);`,
+			want: []string{"ALTER TABLE messages\n    ADD COLUMN payload JSON NULL", "CREATE INDEX idx_a ON a (b)"},
+		},
+		{
+			name:    "trailing statement without semicolon",
+			content: "DROP TABLE a;\nDROP TABLE b\n",
+			want:    []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name:    "blank lines inside a statement",
+			content: "CREATE TABLE a\n\n(\n    id INT\n);",
+			want:    []string{"CREATE TABLE a\n(\n    id INT\n)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := statements(test.content); !slices.Equal(got, test.want) {
+				t.Fatalf("statements() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"20250102000000.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"20250102000000.down.sql": {Data: []byte("DROP TABLE b;")},
+		"20250101000000.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"README.md":               {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != "20250101000000" || migrations[1].Version != "20250102000000" {
+		t.Fatalf("Load() = %+v, want both versions in order", migrations)
+	}
+	if migrations[0].Down != "" || migrations[1].Down != "DROP TABLE b;" || migrations[0].Checksum == "" {
+		t.Fatalf("Load() = %+v, want the down files and checksums", migrations)
+	}
+
+	if _, err := Load(fstest.MapFS{"20250101000000.down.sql": {Data: []byte("DROP TABLE a;")}}); err == nil {
+		t.Fatal("Load() without an up file should fail")
+	}
+}
@@ -0,0 +1,273 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+)
+
+const (
+	lockName    = "orchestrator.migrations"
+	lockTimeout = 60
+)
+
+var createSchemaTable = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    VARCHAR (32) NOT NULL PRIMARY KEY,
+    checksum   CHAR (64) NOT NULL,
+    applied_at TIMESTAMP (3) NOT NULL DEFAULT CURRENT_TIMESTAMP (3)
+)
+    engine = innodb
+    DEFAULT charset = utf8mb4
+    COLLATE = utf8mb4_unicode_ci`
+
+// Status is the state of a migration in the database, Drifted is set when the applied checksum
+// differs from the file
+type Status struct {
+	Version   string
+	Applied   bool
+	AppliedAt *time.Time
+	Drifted   bool
+}
+
+type Migrator interface {
+	// Up applies every pending migration
+	Up(ctx context.Context) error
+	// Down reverts the last applied migration
+	Down(ctx context.Context) error
+	// To applies or reverts migrations until version is the last applied one, "0" reverts everything
+	To(ctx context.Context, version string) error
+	Status(ctx context.Context) ([]Status, error)
+}
+
+// NewMigrator return a MySQL migrator, every run holds an advisory lock so that concurrent pods
+// apply the migrations once, and refuses to run when an applied migration has drifted
+func NewMigrator(db *sql.DB, migrations []Migration) Migrator {
+	return &mysqlImpl{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+type mysqlImpl struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+type appliedVersion struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *mysqlImpl) Up(ctx context.Context) error {
+	return m.run(ctx, func(conn *sql.Conn, applied map[string]appliedVersion) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *mysqlImpl) Down(ctx context.Context) error {
+	return m.run(ctx, func(conn *sql.Conn, applied map[string]appliedVersion) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.revert(ctx, conn, m.migrations[i])
+			}
+		}
+		logger.Infof("No migration to revert")
+		return nil
+	})
+}
+
+func (m *mysqlImpl) To(ctx context.Context, version string) error {
+	if version != "0" && !m.known(version) {
+		return fmt.Errorf("unknown migration version %s", version)
+	}
+
+	return m.run(ctx, func(conn *sql.Conn, applied map[string]appliedVersion) error {
+		reverts, applies := m.plan(applied, version)
+		for _, migration := range reverts {
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		for _, migration := range applies {
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// plan returns the applied migrations above version newest first, then the pending ones up to version oldest first
+func (m *mysqlImpl) plan(applied map[string]appliedVersion, version string) ([]Migration, []Migration) {
+	reverts := make([]Migration, 0)
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			reverts = append(reverts, migration)
+		}
+	}
+	applies := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			applies = append(applies, migration)
+		}
+	}
+	return reverts, applies
+}
+
+func (m *mysqlImpl) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version}
+		if version, ok := applied[migration.Version]; ok {
+			appliedAt := version.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Drifted = version.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// run holds the advisory lock on a dedicated connection, GET_LOCK is bound to the session
+func (m *mysqlImpl) run(ctx context.Context, block func(conn *sql.Conn, applied map[string]appliedVersion) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&locked); err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return errors.New("another migration is running, failed to take the migration lock")
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			logger.WithFields(logger.Fields{
+				"error": err,
+			}).Errorf("Failed to release the migration lock")
+		}
+	}()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	return block(conn, applied)
+}
+
+func (m *mysqlImpl) applied(ctx context.Context, conn *sql.Conn) (map[string]appliedVersion, error) {
+	if _, err := conn.ExecContext(ctx, createSchemaTable); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]appliedVersion)
+	for rows.Next() {
+		var version string
+		var row appliedVersion
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = row
+	}
+	return applied, rows.Err()
+}
+
+// verify fails when an applied migration was edited or removed since it ran
+func (m *mysqlImpl) verify(applied map[string]appliedVersion) error {
+	checksums := make(map[string]string, len(m.migrations))
+	for _, migration := range m.migrations {
+		checksums[migration.Version] = migration.Checksum
+	}
+
+	for version, row := range applied {
+		checksum, ok := checksums[version]
+		if !ok {
+			return fmt.Errorf("applied migration %s has no file", version)
+		}
+		if checksum != row.checksum {
+			return fmt.Errorf("checksum of migration %s drifted, applied %s but the file is %s", version, row.checksum, checksum)
+		}
+	}
+	return nil
+}
+
+// apply runs the up statements then records the version, MySQL commits DDL implicitly so a failing
+// migration may be partially applied and has to be fixed by hand
+func (m *mysqlImpl) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	logger.WithFields(logger.Fields{
+		"version": migration.Version,
+	}).Infof("Applying migration")
+
+	for _, statement := range statements(migration.Up) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.Version, err)
+		}
+	}
+	_, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, checksum) VALUES (?, ?)", migration.Version, migration.Checksum)
+	return err
+}
+
+func (m *mysqlImpl) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %s has no down file", migration.Version)
+	}
+
+	logger.WithFields(logger.Fields{
+		"version": migration.Version,
+	}).Infof("Reverting migration")
+
+	for _, statement := range statements(migration.Down) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.Version, err)
+		}
+	}
+	_, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	return err
+}
+
+func (m *mysqlImpl) known(version string) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"slices"
+	"testing"
+)
+
+func versions(migrations []Migration) []string {
+	result := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func TestPlan(t *testing.T) {
+	m := &mysqlImpl{migrations: []Migration{{Version: "1"}, {Version: "2"}, {Version: "3"}, {Version: "4"}}}
+
+	tests := []struct {
+		name        string
+		applied     []string
+		version     string
+		wantReverts []string
+		wantApplies []string
+	}{
+		{
+			name:        "apply everything from scratch",
+			version:     "4",
+			wantReverts: []string{},
+			wantApplies: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:        "apply up to a version",
+			applied:     []string{"1"},
+			version:     "3",
+			wantReverts: []string{},
+			wantApplies: []string{"2", "3"},
+		},
+		{
+			name:        "revert newest first",
+			applied:     []string{"1", "2", "3", "4"},
+			version:     "1",
+			wantReverts: []string{"4", "3", "2"},
+			wantApplies: []string{},
+		},
+		{
+			name:        "revert everything",
+			applied:     []string{"1", "2"},
+			version:     "0",
+			wantReverts: []string{"2", "1"},
+			wantApplies: []string{},
+		},
+		{
+			name:        "already at the version",
+			applied:     []string{"1", "2"},
+			version:     "2",
+			wantReverts: []string{},
+			wantApplies: []string{},
+		},
+		{
+			name:        "fill a gap below the version and revert above it",
+			applied:     []string{"1", "3", "4"},
+			version:     "3",
+			wantReverts: []string{"4"},
+			wantApplies: []string{"2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			applied := make(map[string]appliedVersion)
+			for _, version := range test.applied {
+				applied[version] = appliedVersion{}
+			}
+			reverts, applies := m.plan(applied, test.version)
+			if got := versions(reverts); !slices.Equal(got, test.wantReverts) {
+				t.Fatalf("reverts = %v, want %v", got, test.wantReverts)
+			}
+			if got := versions(applies); !slices.Equal(got, test.wantApplies) {
+				t.Fatalf("applies = %v, want %v", got, test.wantApplies)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	AttachmentStatusPending  = "pending"
+	AttachmentStatusUploaded = "uploaded"
+)
+
+// Attachment is a file uploaded to the blob store, it is pending until the upload is verified
+// and belongs to a message once sent with it
+type Attachment struct {
+	Id         int    `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	Uploader   string `gorm:"column:uploader;type:varchar(255);not null;index"`
+	MessageId  *int   `gorm:"column:message_id;index;size:32"`
+	StorageKey string `gorm:"column:storage_key;type:varchar(255);not null;unique"`
+	FileName   string `gorm:"column:file_name;type:varchar(255);not null"`
+	MimeType   string `gorm:"column:mime_type;type:varchar(255);not null"`
+	Size       int64  `gorm:"column:size;not null"`
+	// Checksum is the hex SHA-256 of the content, set with the dimensions of images once uploaded
+	Checksum  string    `gorm:"column:checksum;type:varchar(64);not null;default:''"`
+	Width     *int      `gorm:"column:width;size:32"`
+	Height    *int      `gorm:"column:height;size:32"`
+	Status    string    `gorm:"column:status;type:varchar(20);not null"`
+	CreatedAt time.Time `gorm:"column:created_at;precision:3;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;precision:3;autoUpdateTime"`
+
+	User    *User    `gorm:"foreignKey:Uploader;references:Identification"`
+	Message *Message `gorm:"foreignKey:MessageId"`
+}
+
+func (a Attachment) IsUploaded() bool {
+	return a.Status == AttachmentStatusUploaded
+}
@@ -2,8 +2,19 @@ package models
 
 import "time"
 
+const (
+	PostPolicyEveryone = "everyone"
+	// PostPolicyAdmins makes an announcement channel, only admins and the owner can post
+	PostPolicyAdmins = "admins"
+)
+
+func IsValidPostPolicy(policy string) bool {
+	return policy == PostPolicyEveryone || policy == PostPolicyAdmins
+}
+
 type Conversation struct {
-	Id        int       `gorm:"column:id;primaryKey;autoIncrement"`
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	Id         int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	PostPolicy string    `gorm:"column:post_policy;type:varchar(20);not null;default:everyone"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime"`
 }
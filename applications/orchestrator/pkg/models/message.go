@@ -3,11 +3,37 @@ package models
 import "time"
 
 type Message struct {
-	Id             int       `gorm:"column:id;primaryKey;autoIncrement"`
-	Sender         string    `gorm:"column:sender;type:varchar(255);not null"`
-	ConversationId int64     `gorm:"column:conversation_id;not null;index"`
-	Content        string    `gorm:"column:content;type:text;not null"`
-	Type           string    `gorm:"column:type;type:varchar(50);not null"`
-	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	Id             int    `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	Sender         string `gorm:"column:sender;type:varchar(255);not null;index:idx_sender;uniqueIndex:unique_sender_conversation_client_message,priority:1"`
+	ConversationId int64  `gorm:"column:conversation_id;not null;index;uniqueIndex:unique_sender_conversation_client_message,priority:2"`
+	Content        string `gorm:"column:content;type:text;not null"`
+	Type           string `gorm:"column:type;type:varchar(50);not null"`
+	// Payload is the JSON of structured types, Content then holds a plain text summary of it
+	Payload         *string   `gorm:"column:payload;type:json"`
+	ClientMessageId *string   `gorm:"column:client_message_id;type:varchar(36);uniqueIndex:unique_sender_conversation_client_message,priority:3"`
+	CreatedAt       time.Time `gorm:"column:created_at;precision:3;autoCreateTime;index:idx_created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;precision:3;autoUpdateTime"`
+	// ParentMessageId is the root of the thread the message replies to, the root keeps the count and time of its replies
+	ParentMessageId *int       `gorm:"column:parent_message_id;index;size:32"`
+	QuotedMessageId *int       `gorm:"column:quoted_message_id;size:32"`
+	ReplyCount      int        `gorm:"column:reply_count;not null;default:0;size:32"`
+	LastReplyAt     *time.Time `gorm:"column:last_reply_at;precision:3"`
+	// EditedAt is the time of the last edit, the previous contents are kept as revisions
+	EditedAt *time.Time `gorm:"column:edited_at;precision:3"`
+	// DeletedAt tombstones the message, the row is kept and listed without its content
+	DeletedAt *time.Time `gorm:"column:deleted_at;precision:3"`
+
+	Conversation *Conversation `gorm:"foreignKey:ConversationId"`
+}
+
+func (m Message) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// Body return the content clients get, the payload of structured types
+func (m Message) Body() string {
+	if m.Payload != nil {
+		return *m.Payload
+	}
+	return m.Content
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// MessageReaction is an emoji a member put on a message, a member reacts at most once with each emoji
+type MessageReaction struct {
+	Id                 int       `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	MessageId          int       `gorm:"column:message_id;not null;size:32;uniqueIndex:unique_message_user_emoji,priority:1"`
+	UserIdentification string    `gorm:"column:user_identification;type:varchar(255);not null;uniqueIndex:unique_message_user_emoji,priority:2"`
+	Emoji              string    `gorm:"column:emoji;type:varchar(64);not null;uniqueIndex:unique_message_user_emoji,priority:3"`
+	CreatedAt          time.Time `gorm:"column:created_at;precision:3;autoCreateTime"`
+
+	Message *Message `gorm:"foreignKey:MessageId"`
+	User    *User    `gorm:"foreignKey:UserIdentification;references:Identification"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// MessageRevision is the content a message had before an edit
+type MessageRevision struct {
+	Id        int       `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	MessageId int       `gorm:"column:message_id;not null;index;size:32"`
+	Content   string    `gorm:"column:content;type:text;not null"`
+	EditedBy  string    `gorm:"column:edited_by;type:varchar(255);not null"`
+	CreatedAt time.Time `gorm:"column:created_at;precision:3;autoCreateTime"`
+
+	Message *Message `gorm:"foreignKey:MessageId"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// All return every model stored in the database, in creation order
+func All() []interface{} {
+	return []interface{}{
+		&User{},
+		&Conversation{},
+		&UserConversation{},
+		&Message{},
+		&MessageRevision{},
+		&MessageReaction{},
+		&Attachment{},
+		&Outbox{},
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusFailed    = "failed"
+)
+
+// Outbox is an event written in the same transaction as the data it describes,
+// the relay publishes it to the servers of the conversation once committed
+type Outbox struct {
+	Id             int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	ConversationId int64      `gorm:"column:conversation_id;not null"`
+	Payload        []byte     `gorm:"column:payload;type:blob;not null"`
+	Status         string     `gorm:"column:status;type:varchar(20);not null;index:idx_status_available_at,priority:1"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0;size:32"`
+	LastError      string     `gorm:"column:last_error;type:text"`
+	AvailableAt    time.Time  `gorm:"column:available_at;not null;index:idx_status_available_at,priority:2"`
+	PublishedAt    *time.Time `gorm:"column:published_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Outbox) TableName() string {
+	return "outbox"
+}
@@ -3,8 +3,8 @@ package models
 import "time"
 
 type User struct {
-	Id             int       `gorm:"column:id;primaryKey;autoIncrement"`
-	Identification string    `gorm:"column:identification;type:varchar(255);unique;not null"`
+	Id             int       `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	Identification string    `gorm:"column:identification;type:varchar(255);unique;not null;index:idx_identification"`
 	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime"`
 }
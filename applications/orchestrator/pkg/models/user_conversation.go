@@ -2,13 +2,51 @@ package models
 
 import "time"
 
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	// RoleReader can read the conversation but not post in it
+	RoleReader = "reader"
+)
+
+// roleRanks orders the roles, a member can only manage the members it outranks
+var roleRanks = map[string]int{
+	RoleReader: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Outranks reports whether role is strictly above other
+func Outranks(role string, other string) bool {
+	return roleRanks[role] > roleRanks[other]
+}
+
+// AtLeast reports whether role is other or above
+func AtLeast(role string, other string) bool {
+	return roleRanks[role] >= roleRanks[other]
+}
+
 type UserConversation struct {
-	Id                 int       `gorm:"column:id;primaryKey;autoIncrement"`
-	UserIdentification string    `gorm:"column:user_identification;type:varchar(255);not null;index"`
-	ConversationId     int       `gorm:"column:conversation_id;not null;index"`
-	Role               string    `gorm:"column:role;type:varchar(50);not null"`
-	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt          time.Time `gorm:"column:updated_at;autoUpdateTime"`
-
-	User *User `gorm:"foreignKey:UserIdentification;references:Identification"`
+	Id                 int    `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	UserIdentification string `gorm:"column:user_identification;type:varchar(255);not null;index;uniqueIndex:unique_user_conversation,priority:1"`
+	ConversationId     int64  `gorm:"column:conversation_id;not null;index;uniqueIndex:unique_user_conversation,priority:2"`
+	Role               string `gorm:"column:role;type:varchar(50);not null"`
+	// LastDeliveredMessageId is the last message acked by a device of the member, later ones are redelivered
+	LastDeliveredMessageId int        `gorm:"column:last_delivered_message_id;not null;default:0;size:32"`
+	LastDeliveredAt        *time.Time `gorm:"column:last_delivered_at;precision:3"`
+	// LastReadMessageId only moves forward, messages with a greater id are unread
+	LastReadMessageId int        `gorm:"column:last_read_message_id;not null;default:0;size:32"`
+	LastReadAt        *time.Time `gorm:"column:last_read_at;precision:3"`
+	CreatedAt         time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User         *User         `gorm:"foreignKey:UserIdentification;references:Identification"`
+	Conversation *Conversation `gorm:"foreignKey:ConversationId"`
 }
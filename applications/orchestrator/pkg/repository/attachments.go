@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+type IAttachments interface {
+	IRepository[models.Attachment]
+	Attach(ctx context.Context, ids []int, uploader string, messageId int) (int64, error)
+}
+
+type attachments struct {
+	IRepository[models.Attachment]
+	db *gorm.DB
+}
+
+func NewAttachments(db *gorm.DB) IAttachments {
+	return &attachments{
+		db:          db,
+		IRepository: New[models.Attachment](db),
+	}
+}
+
+// Attach links the uploaded attachments of the uploader that are not linked yet to the message,
+// it return how many were linked
+func (a *attachments) Attach(ctx context.Context, ids []int, uploader string, messageId int) (int64, error) {
+	result := a.db.WithContext(ctx).
+		Model(&models.Attachment{}).
+		Where("id IN ? AND uploader = ? AND status = ? AND message_id IS NULL", ids, uploader, models.AttachmentStatusUploaded).
+		Update("message_id", messageId)
+	return result.RowsAffected, result.Error
+}
+
+type AttachmentFilter struct {
+	Id         *int
+	Ids        []int
+	MessageIds []int
+}
+
+func (a AttachmentFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if a.Id != nil {
+		db = db.Where("id = ?", *a.Id)
+	}
+
+	if a.Ids != nil {
+		db = db.Where("id IN ?", a.Ids)
+	}
+
+	if a.MessageIds != nil {
+		db = db.Where("message_id IN ?", a.MessageIds)
+	}
+
+	return db.Order("id ASC")
+}
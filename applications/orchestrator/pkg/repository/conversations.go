@@ -20,3 +20,15 @@ func NewConversations(db *gorm.DB) IConversations {
 		IRepository: New[models.Conversation](db),
 	}
 }
+
+type ConversationFilter struct {
+	Id *int64
+}
+
+func (c ConversationFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if c.Id != nil {
+		db = db.Where("id = ?", *c.Id)
+	}
+
+	return db
+}
@@ -19,6 +19,7 @@ type IRepository[T any] interface {
 	SaveMany(context.Context, []T) ([]T, error)
 	SaveManyIgnoreConflicts(context.Context, []T) ([]T, error)
 	Exec(context.Context, string, ...interface{}) error
+	Delete(ctx context.Context, filter IFilter) error
 }
 
 type IFilter interface {
@@ -105,3 +106,8 @@ func (c Repository[T]) Exec(ctx context.Context, sql string, values ...interface
 	err := c.DB.WithContext(ctx).Exec(sql, values...).Error
 	return err
 }
+
+func (c Repository[T]) Delete(ctx context.Context, filter IFilter) error {
+	var record T
+	return filter.ApplyFilter(c.DB.WithContext(ctx)).Delete(&record).Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+type IMessageReactions interface {
+	IRepository[models.MessageReaction]
+	Aggregate(ctx context.Context, messageIds []int, userIdentification string) ([]ReactionCount, error)
+}
+
+// ReactionCount is the number of members who reacted to a message with an emoji, Reacted tells whether
+// the requesting user is one of them
+type ReactionCount struct {
+	MessageId int    `gorm:"column:message_id"`
+	Emoji     string `gorm:"column:emoji"`
+	Count     int64  `gorm:"column:count"`
+	Reacted   bool   `gorm:"column:reacted"`
+}
+
+type messageReactions struct {
+	IRepository[models.MessageReaction]
+	db *gorm.DB
+}
+
+func NewMessageReactions(db *gorm.DB) IMessageReactions {
+	return &messageReactions{
+		db:          db,
+		IRepository: New[models.MessageReaction](db),
+	}
+}
+
+// Aggregate return the reaction counts of the messages, emojis of a message are in the order they were
+// first used
+func (m *messageReactions) Aggregate(ctx context.Context, messageIds []int, userIdentification string) ([]ReactionCount, error) {
+	counts := make([]ReactionCount, 0)
+	if len(messageIds) == 0 {
+		return counts, nil
+	}
+
+	err := m.db.WithContext(ctx).
+		Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, MAX(CASE WHEN user_identification = ? THEN 1 ELSE 0 END) AS reacted", userIdentification).
+		Where("message_id IN ?", messageIds).
+		Group("message_id, emoji").
+		Order("message_id, MIN(id)").
+		Scan(&counts).Error
+	return counts, err
+}
+
+type MessageReactionFilter struct {
+	MessageId          *int
+	UserIdentification *string
+	Emoji              *string
+}
+
+func (m MessageReactionFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if m.MessageId != nil {
+		db = db.Where("message_id = ?", *m.MessageId)
+	}
+
+	if m.UserIdentification != nil {
+		db = db.Where("user_identification = ?", *m.UserIdentification)
+	}
+
+	if m.Emoji != nil {
+		db = db.Where("emoji = ?", *m.Emoji)
+	}
+
+	return db
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+type IMessageRevisions interface {
+	IRepository[models.MessageRevision]
+}
+
+type messageRevisions struct {
+	IRepository[models.MessageRevision]
+	db *gorm.DB
+}
+
+func NewMessageRevisions(db *gorm.DB) IMessageRevisions {
+	return &messageRevisions{
+		db:          db,
+		IRepository: New[models.MessageRevision](db),
+	}
+}
+
+type MessageRevisionFilter struct {
+	MessageId *int
+}
+
+// ApplyFilter return the revisions oldest first
+func (m MessageRevisionFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if m.MessageId != nil {
+		db = db.Where("message_id = ?", *m.MessageId)
+	}
+
+	return db.Order("id ASC")
+}
@@ -1,12 +1,29 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
 )
 
 type IMessages interface {
 	IRepository[models.Message]
+	Undelivered(ctx context.Context, userIdentification string, limit int) ([]models.Message, error)
+	Replay(ctx context.Context, userIdentification string, cursor ResumeCursor, afterId int, limit int) ([]models.Message, error)
+	Edit(ctx context.Context, id int, content string, payload *string, editedAt time.Time) (bool, error)
+	Tombstone(ctx context.Context, id int, deletedAt time.Time) (bool, error)
+	AddReply(ctx context.Context, rootId int, repliedAt time.Time) error
+	RemoveReply(ctx context.Context, rootId int) error
+}
+
+// ResumeCursor is the last message a client got, per conversation or for every conversation
+// that is not listed
+type ResumeCursor struct {
+	Global        *int
+	Conversations map[int64]int
 }
 
 type messages struct {
@@ -20,3 +37,159 @@ func NewMessages(db *gorm.DB) IMessages {
 		IRepository: New[models.Message](db),
 	}
 }
+
+// Undelivered return the oldest messages from other members that the user has not acked, messages
+// sent before the user joined a conversation are left out
+func (m *messages) Undelivered(ctx context.Context, userIdentification string, limit int) ([]models.Message, error) {
+	records := make([]models.Message, 0)
+	err := m.db.WithContext(ctx).
+		Table("messages AS m").
+		Select("m.*").
+		Joins("JOIN user_conversations AS uc ON uc.conversation_id = m.conversation_id AND uc.user_identification = ?", userIdentification).
+		Where("m.id > uc.last_delivered_message_id AND m.sender <> ? AND m.created_at >= uc.created_at", userIdentification).
+		Order("m.id ASC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+// Replay return the messages of the conversations of the user past the cursor and past afterId, in id order,
+// a conversation without a cursor is only replayed when the global cursor is set
+func (m *messages) Replay(ctx context.Context, userIdentification string, cursor ResumeCursor, afterId int, limit int) ([]models.Message, error) {
+	query := m.db.WithContext(ctx)
+	conversationIds := make([]int64, 0, len(cursor.Conversations))
+	for conversationId, messageId := range cursor.Conversations {
+		query = query.Or("m.conversation_id = ? AND m.id > ?", conversationId, messageId)
+		conversationIds = append(conversationIds, conversationId)
+	}
+	if cursor.Global != nil {
+		if len(conversationIds) > 0 {
+			query = query.Or("m.conversation_id NOT IN ? AND m.id > ?", conversationIds, *cursor.Global)
+		} else {
+			query = query.Or("m.id > ?", *cursor.Global)
+		}
+	}
+
+	records := make([]models.Message, 0)
+	err := m.db.WithContext(ctx).
+		Table("messages AS m").
+		Select("m.*").
+		Joins("JOIN user_conversations AS uc ON uc.conversation_id = m.conversation_id AND uc.user_identification = ?", userIdentification).
+		Where("m.id > ? AND m.created_at >= uc.created_at", afterId).
+		Where(query).
+		Order("m.id ASC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+// Edit replaces the content and payload of a message that is not deleted, it reports false when the message is deleted
+func (m *messages) Edit(ctx context.Context, id int, content string, payload *string, editedAt time.Time) (bool, error) {
+	result := m.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"content":   content,
+			"payload":   payload,
+			"edited_at": editedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Tombstone marks a message as deleted, it reports false when the message already is
+func (m *messages) Tombstone(ctx context.Context, id int, deletedAt time.Time) (bool, error) {
+	result := m.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", deletedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddReply counts a new reply on the root of a thread
+func (m *messages) AddReply(ctx context.Context, rootId int, repliedAt time.Time) error {
+	return m.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ?", rootId).
+		Updates(map[string]interface{}{
+			"reply_count":   gorm.Expr("reply_count + 1"),
+			"last_reply_at": repliedAt,
+		}).Error
+}
+
+// RemoveReply uncounts a deleted reply on the root of a thread, the time of the last reply is kept
+func (m *messages) RemoveReply(ctx context.Context, rootId int) error {
+	return m.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ? AND reply_count > 0", rootId).
+		Update("reply_count", gorm.Expr("reply_count - 1")).Error
+}
+
+type MessageFilter struct {
+	Id              *int
+	Ids             []int
+	Sender          *string
+	ConversationId  *int64
+	ClientMessageId *string
+	ParentMessageId *int
+
+	// Before and After select the page older or newer than the cursor, newest first unless After is set
+	Before *MessageCursor
+	After  *MessageCursor
+	Limit  int
+
+	// ForUpdate locks the selected rows until the transaction ends
+	ForUpdate bool
+}
+
+// MessageCursor is the position of a message in the (created_at, id) order of a conversation
+type MessageCursor struct {
+	CreatedAt time.Time
+	Id        int
+}
+
+func (m MessageFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if m.Id != nil {
+		db = db.Where("id = ?", *m.Id)
+	}
+
+	if m.Ids != nil {
+		db = db.Where("id IN ?", m.Ids)
+	}
+
+	if m.Sender != nil {
+		db = db.Where("sender = ?", *m.Sender)
+	}
+
+	if m.ConversationId != nil {
+		db = db.Where("conversation_id = ?", *m.ConversationId)
+	}
+
+	if m.ClientMessageId != nil {
+		db = db.Where("client_message_id = ?", *m.ClientMessageId)
+	}
+
+	if m.ParentMessageId != nil {
+		db = db.Where("parent_message_id = ?", *m.ParentMessageId)
+	}
+
+	if m.Before != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", m.Before.CreatedAt, m.Before.CreatedAt, m.Before.Id)
+	}
+
+	if m.After != nil {
+		db = db.Where("(created_at > ? OR (created_at = ? AND id > ?))", m.After.CreatedAt, m.After.CreatedAt, m.After.Id)
+		db = db.Order("created_at ASC").Order("id ASC")
+	} else {
+		db = db.Order("created_at DESC").Order("id DESC")
+	}
+
+	if m.Limit > 0 {
+		db = db.Limit(m.Limit)
+	}
+
+	if m.ForUpdate {
+		db = db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate})
+	}
+
+	return db
+}
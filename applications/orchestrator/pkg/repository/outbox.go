@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+)
+
+type IOutbox interface {
+	IRepository[models.Outbox]
+	DeletePublishedBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type outbox struct {
+	IRepository[models.Outbox]
+	db *gorm.DB
+}
+
+func NewOutbox(db *gorm.DB) IOutbox {
+	return &outbox{
+		db:          db,
+		IRepository: New[models.Outbox](db),
+	}
+}
+
+// DeletePublishedBefore deletes at most limit events published before the given time and return how many
+// were deleted. The ids are selected first as DELETE ... LIMIT is not portable
+func (o *outbox) DeletePublishedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var ids []int64
+	err := o.db.WithContext(ctx).
+		Model(&models.Outbox{}).
+		Where("status = ? AND published_at < ?", models.OutboxStatusPublished, before).
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	result := o.db.WithContext(ctx).Where("id IN ?", ids).Delete(&models.Outbox{})
+	return result.RowsAffected, result.Error
+}
+
+type OutboxFilter struct {
+	Status          *string
+	AvailableBefore *time.Time
+	Limit           int
+
+	// SkipLocked locks the selected rows and skips the ones locked by another relay
+	SkipLocked bool
+}
+
+func (o OutboxFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	if o.Status != nil {
+		db = db.Where("status = ?", *o.Status)
+	}
+
+	if o.AvailableBefore != nil {
+		db = db.Where("available_at <= ?", *o.AvailableBefore)
+	}
+
+	if o.SkipLocked {
+		db = db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked})
+	}
+
+	if o.Limit > 0 {
+		db = db.Limit(o.Limit)
+	}
+
+	return db.Order("id ASC")
+}
@@ -10,14 +10,22 @@ import (
 type IStore interface {
 	Users() repository.IUsers
 	Messages() repository.IMessages
+	MessageRevisions() repository.IMessageRevisions
+	MessageReactions() repository.IMessageReactions
+	Attachments() repository.IAttachments
 	Conversations() repository.IConversations
 	UserConversations() repository.IUserConversations
+	Outbox() repository.IOutbox
 }
 type store struct {
 	users             repository.IUsers
 	messages          repository.IMessages
+	messageRevisions  repository.IMessageRevisions
+	messageReactions  repository.IMessageReactions
+	attachments       repository.IAttachments
 	conversations     repository.IConversations
 	userConversations repository.IUserConversations
+	outbox            repository.IOutbox
 }
 
 func (s *store) Users() repository.IUsers {
@@ -28,6 +36,18 @@ func (s *store) Messages() repository.IMessages {
 	return s.messages
 }
 
+func (s *store) MessageRevisions() repository.IMessageRevisions {
+	return s.messageRevisions
+}
+
+func (s *store) MessageReactions() repository.IMessageReactions {
+	return s.messageReactions
+}
+
+func (s *store) Attachments() repository.IAttachments {
+	return s.attachments
+}
+
 func (s *store) Conversations() repository.IConversations {
 	return s.conversations
 }
@@ -36,6 +56,10 @@ func (s *store) UserConversations() repository.IUserConversations {
 	return s.userConversations
 }
 
+func (s *store) Outbox() repository.IOutbox {
+	return s.outbox
+}
+
 type worker struct {
 	db *gorm.DB
 }
@@ -55,8 +79,12 @@ func (s *worker) Do(_ context.Context, block Block) error {
 		newStore := &store{
 			users:             repository.NewUsers(tx),
 			messages:          repository.NewMessages(tx),
+			messageRevisions:  repository.NewMessageRevisions(tx),
+			messageReactions:  repository.NewMessageReactions(tx),
+			attachments:       repository.NewAttachments(tx),
 			conversations:     repository.NewConversations(tx),
 			userConversations: repository.NewUserConversations(tx),
+			outbox:            repository.NewOutbox(tx),
 		}
 		return block(newStore)
 	})
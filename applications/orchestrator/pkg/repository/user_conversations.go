@@ -1,12 +1,26 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
 )
 
 type IUserConversations interface {
 	IRepository[models.UserConversation]
+	AdvanceLastDelivered(ctx context.Context, conversationId int64, userIdentification string, messageId int, deliveredAt time.Time) (bool, error)
+	AdvanceLastRead(ctx context.Context, conversationId int64, userIdentification string, messageId int, readAt time.Time) (bool, error)
+	UnreadCounts(ctx context.Context, userIdentification string) ([]UnreadCount, error)
+	UpdateRole(ctx context.Context, id int, role string) error
+}
+
+// UnreadCount is the number of messages from other members after the last read message of a conversation
+type UnreadCount struct {
+	ConversationId    int64 `gorm:"column:conversation_id"`
+	LastReadMessageId int   `gorm:"column:last_read_message_id"`
+	Unread            int64 `gorm:"column:unread"`
 }
 
 type userConversations struct {
@@ -21,8 +35,54 @@ func NewUserConversations(db *gorm.DB) IUserConversations {
 	}
 }
 
+func (u *userConversations) AdvanceLastDelivered(ctx context.Context, conversationId int64, userIdentification string, messageId int, deliveredAt time.Time) (bool, error) {
+	return u.advance(ctx, "last_delivered", conversationId, userIdentification, messageId, deliveredAt)
+}
+
+func (u *userConversations) AdvanceLastRead(ctx context.Context, conversationId int64, userIdentification string, messageId int, readAt time.Time) (bool, error) {
+	return u.advance(ctx, "last_read", conversationId, userIdentification, messageId, readAt)
+}
+
+// advance moves the <cursor>_message_id of the member to messageId, it reports false and leaves the row
+// untouched when the cursor is already there or further
+func (u *userConversations) advance(ctx context.Context, cursor string, conversationId int64, userIdentification string, messageId int, at time.Time) (bool, error) {
+	result := u.db.WithContext(ctx).
+		Model(&models.UserConversation{}).
+		Where("conversation_id = ? AND user_identification = ? AND "+cursor+"_message_id < ?", conversationId, userIdentification, messageId).
+		Updates(map[string]interface{}{
+			cursor + "_message_id": messageId,
+			cursor + "_at":         at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateRole only writes the role, the other columns of the member may be updated concurrently
+func (u *userConversations) UpdateRole(ctx context.Context, id int, role string) error {
+	return u.db.WithContext(ctx).
+		Model(&models.UserConversation{}).
+		Where("id = ?", id).
+		Update("role", role).Error
+}
+
+// UnreadCounts counts the messages of the others after the read cursor of each conversation, the messages
+// sent before the user joined are not unread
+func (u *userConversations) UnreadCounts(ctx context.Context, userIdentification string) ([]UnreadCount, error) {
+	counts := make([]UnreadCount, 0)
+	err := u.db.WithContext(ctx).
+		Table("user_conversations AS uc").
+		Select("uc.conversation_id, uc.last_read_message_id, COUNT(m.id) AS unread").
+		Joins("LEFT JOIN messages AS m ON m.conversation_id = uc.conversation_id AND m.id > uc.last_read_message_id AND m.created_at >= uc.created_at AND m.sender <> uc.user_identification AND m.deleted_at IS NULL").
+		Where("uc.user_identification = ?", userIdentification).
+		Group("uc.conversation_id, uc.last_read_message_id").
+		Order("uc.conversation_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
 type UserConversationFilter struct {
-	ConversationId *int64
+	ConversationId      *int64
+	UserIdentification  *string
+	UserIdentifications []string
 
 	PreloadOption *UserConversationPreloadOption
 }
@@ -37,5 +97,13 @@ func (u UserConversationFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
 		db = db.Where("conversation_id = ?", *u.ConversationId)
 	}
 
+	if u.UserIdentification != nil {
+		db = db.Where("user_identification = ?", *u.UserIdentification)
+	}
+
+	if len(u.UserIdentifications) > 0 {
+		db = db.Where("user_identification IN ?", u.UserIdentifications)
+	}
+
 	return db
 }
@@ -0,0 +1,23 @@
+package schemacheck
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// RequireMatch fails the test when the models and the database disagree, listing every mismatch
+func RequireMatch(t testing.TB, db *gorm.DB, models ...interface{}) {
+	t.Helper()
+
+	mismatches, err := Verify(db, models...)
+	if err != nil {
+		t.Fatalf("failed to verify schema: %s", err)
+	}
+	for _, mismatch := range mismatches {
+		t.Errorf("schema mismatch: %s", mismatch)
+	}
+	if len(mismatches) > 0 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,337 @@
+package schemacheck
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	KindTable      = "table"
+	KindColumn     = "column"
+	KindType       = "type"
+	KindNullable   = "nullable"
+	KindIndex      = "index"
+	KindForeignKey = "foreign_key"
+)
+
+// Mismatch is a difference between a model and the live table, an empty Expected means the database
+// has something the model does not declare and an empty Actual the opposite
+type Mismatch struct {
+	Table    string
+	Kind     string
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s %s %s: model %q, database %q", m.Table, m.Kind, m.Name, m.Expected, m.Actual)
+}
+
+// Verify compares the models, as parsed by gorm, with the tables of the database and return every
+// column type, nullability, index and foreign key mismatch
+func Verify(db *gorm.DB, models ...interface{}) ([]Mismatch, error) {
+	mismatches := make([]Mismatch, 0)
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		sch := stmt.Schema
+
+		if !db.Migrator().HasTable(model) {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindTable, Name: sch.Table, Expected: sch.Table})
+			continue
+		}
+
+		columns, uniqueColumns, err := verifyColumns(db, sch, model)
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys, actualForeignKeys, err := verifyForeignKeys(db, sch)
+		if err != nil {
+			return nil, err
+		}
+		indexes, err := verifyIndexes(db, sch, model, uniqueColumns, actualForeignKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		mismatches = append(mismatches, columns...)
+		mismatches = append(mismatches, indexes...)
+		mismatches = append(mismatches, foreignKeys...)
+	}
+	return mismatches, nil
+}
+
+// verifyColumns return the column mismatches and the columns the database reports as unique
+func verifyColumns(db *gorm.DB, sch *schema.Schema, model interface{}) ([]Mismatch, map[string]bool, error) {
+	columnTypes, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actual := make(map[string]gorm.ColumnType, len(columnTypes))
+	uniqueColumns := make(map[string]bool)
+	for _, columnType := range columnTypes {
+		actual[columnType.Name()] = columnType
+		if unique, ok := columnType.Unique(); ok && unique {
+			uniqueColumns[columnType.Name()] = true
+		}
+	}
+
+	mismatches := make([]Mismatch, 0)
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		columnType, ok := actual[field.DBName]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindColumn, Name: field.DBName, Expected: field.DBName})
+			continue
+		}
+		delete(actual, field.DBName)
+
+		expectedType := normalizeType(db.Dialector.DataTypeOf(field))
+		actualType, ok := columnType.ColumnType()
+		if !ok {
+			actualType = columnType.DatabaseTypeName()
+		}
+		actualType = normalizeType(actualType)
+		// gorm gives times a default precision, it is only compared when the model declares one
+		if field.DataType == schema.Time && field.Precision == 0 {
+			expectedType, actualType = withoutPrecision(expectedType), withoutPrecision(actualType)
+		}
+		if actualType != expectedType {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindType, Name: field.DBName, Expected: expectedType, Actual: actualType})
+		}
+
+		// primary keys are implicitly not null, SQLite reports an INTEGER PRIMARY KEY as nullable
+		if field.PrimaryKey {
+			continue
+		}
+		if nullable, ok := columnType.Nullable(); ok && nullable != !field.NotNull {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindNullable, Name: field.DBName, Expected: nullability(!field.NotNull), Actual: nullability(nullable)})
+		}
+	}
+
+	for name := range actual {
+		mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindColumn, Name: name, Actual: name})
+	}
+	return mismatches, uniqueColumns, nil
+}
+
+// verifyIndexes matches indexes on their columns and uniqueness, names differ between gorm and the
+// migrations. Unique columns and the indexes backing foreign keys count as declared, a unique column
+// may also be enforced by a constraint the dialect does not list as an index. Full-text indexes are left
+// out, they are owned by the migrations since SQLite cannot build them from the models
+func verifyIndexes(db *gorm.DB, sch *schema.Schema, model interface{}, uniqueColumns map[string]bool, foreignKeys []foreignKey) ([]Mismatch, error) {
+	indexes, err := db.Migrator().GetIndexes(model)
+	if err != nil {
+		return nil, err
+	}
+	fullText, err := fullTextIndexes(db, sch.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make([]gorm.Index, 0, len(indexes))
+	for _, index := range indexes {
+		if primary, _ := index.PrimaryKey(); !primary && !slices.Contains(fullText, index.Name()) {
+			actual = append(actual, index)
+		}
+	}
+	matched := make([]bool, len(actual))
+	find := func(columns []string, unique bool) bool {
+		for i, index := range actual {
+			actualUnique, _ := index.Unique()
+			if actualUnique == unique && slices.Equal(index.Columns(), columns) {
+				matched[i] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	mismatches := make([]Mismatch, 0)
+	for _, index := range sch.ParseIndexes() {
+		columns := make([]string, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			columns = append(columns, option.DBName)
+		}
+		unique := index.Class == "UNIQUE"
+		if !find(columns, unique) {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindIndex, Name: index.Name, Expected: describeIndex(columns, unique)})
+		}
+	}
+	for _, field := range sch.Fields {
+		if field.Unique && !find([]string{field.DBName}, true) && !uniqueColumns[field.DBName] {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindIndex, Name: field.DBName, Expected: describeIndex([]string{field.DBName}, true)})
+		}
+	}
+	for _, fk := range foreignKeys {
+		for i, index := range actual {
+			if len(index.Columns()) > 0 && index.Columns()[0] == fk.column {
+				matched[i] = true
+			}
+		}
+	}
+
+	for i, index := range actual {
+		if !matched[i] {
+			unique, _ := index.Unique()
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindIndex, Name: index.Name(), Actual: describeIndex(index.Columns(), unique)})
+		}
+	}
+	return mismatches, nil
+}
+
+func fullTextIndexes(db *gorm.DB, table string) ([]string, error) {
+	names := make([]string, 0)
+	if db.Dialector.Name() != "mysql" {
+		return names, nil
+	}
+
+	err := db.Raw(`SELECT DISTINCT INDEX_NAME FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_TYPE = 'FULLTEXT'`, table).Scan(&names).Error
+	return names, err
+}
+
+type foreignKey struct {
+	column           string
+	referencedTable  string
+	referencedColumn string
+}
+
+func (f foreignKey) String() string {
+	return fmt.Sprintf("%s -> %s.%s", f.column, f.referencedTable, f.referencedColumn)
+}
+
+// verifyForeignKeys compares the constraints gorm derives from the relationships of the model
+// with the foreign keys of the table, and return the latter for the index check
+func verifyForeignKeys(db *gorm.DB, sch *schema.Schema) ([]Mismatch, []foreignKey, error) {
+	expected := make([]foreignKey, 0)
+	for _, relationship := range sch.Relationships.Relations {
+		constraint := relationship.ParseConstraint()
+		if constraint == nil || constraint.Schema != sch {
+			continue
+		}
+		for i, field := range constraint.ForeignKeys {
+			expected = append(expected, foreignKey{
+				column:           field.DBName,
+				referencedTable:  constraint.ReferenceSchema.Table,
+				referencedColumn: constraint.References[i].DBName,
+			})
+		}
+	}
+
+	actual, err := foreignKeys(db, sch.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mismatches := make([]Mismatch, 0)
+	for _, fk := range expected {
+		if !slices.Contains(actual, fk) {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindForeignKey, Name: fk.column, Expected: fk.String()})
+		}
+	}
+	for _, fk := range actual {
+		if !slices.Contains(expected, fk) {
+			mismatches = append(mismatches, Mismatch{Table: sch.Table, Kind: KindForeignKey, Name: fk.column, Actual: fk.String()})
+		}
+	}
+	return mismatches, actual, nil
+}
+
+func foreignKeys(db *gorm.DB, table string) ([]foreignKey, error) {
+	result := make([]foreignKey, 0)
+	switch db.Dialector.Name() {
+	case "mysql":
+		rows, err := db.Raw(`SELECT COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND REFERENCED_TABLE_NAME IS NOT NULL`, table).Rows()
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var fk foreignKey
+			if err := rows.Scan(&fk.column, &fk.referencedTable, &fk.referencedColumn); err != nil {
+				return nil, err
+			}
+			result = append(result, fk)
+		}
+		return result, rows.Err()
+	case "sqlite":
+		rows, err := db.Raw(`SELECT "from", "table", "to" FROM pragma_foreign_key_list(?)`, table).Rows()
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var fk foreignKey
+			if err := rows.Scan(&fk.column, &fk.referencedTable, &fk.referencedColumn); err != nil {
+				return nil, err
+			}
+			result = append(result, fk)
+		}
+		return result, rows.Err()
+	default:
+		return nil, fmt.Errorf("foreign keys of %s are not supported", db.Dialector.Name())
+	}
+}
+
+var (
+	// integer display widths such as int(11) are ignored by MySQL 8
+	integerWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	typeAliases  = map[string]string{
+		"integer":   "int",
+		"timestamp": "datetime",
+	}
+)
+
+// normalizeType reduces a column type to the parts both sides agree on, auto increment, primary key and
+// nullability are part of the data type for some dialects
+func normalizeType(columnType string) string {
+	normalized := strings.ToLower(strings.TrimSpace(columnType))
+	for _, suffix := range []string{"primary key autoincrement", "auto_increment", "autoincrement", "primary key", "unsigned", "not null", "null"} {
+		normalized = strings.TrimSpace(strings.ReplaceAll(normalized, suffix, ""))
+	}
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	normalized = integerWidth.ReplaceAllString(normalized, "$1")
+
+	base, rest, _ := strings.Cut(normalized, "(")
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	if rest != "" {
+		return base + "(" + rest
+	}
+	return base
+}
+
+func withoutPrecision(columnType string) string {
+	base, _, _ := strings.Cut(columnType, "(")
+	return base
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func describeIndex(columns []string, unique bool) string {
+	description := "(" + strings.Join(columns, ", ") + ")"
+	if unique {
+		return "UNIQUE " + description
+	}
+	return description
+}
@@ -0,0 +1,102 @@
+package schemacheck
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type author struct {
+	Id        int       `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null;unique"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+type post struct {
+	Id       int        `gorm:"column:id;primaryKey;autoIncrement;size:32"`
+	AuthorId int        `gorm:"column:author_id;not null;size:32"`
+	Title    string     `gorm:"column:title;type:varchar(255);not null;index:idx_title"`
+	EditedAt *time.Time `gorm:"column:edited_at;precision:3"`
+
+	Author *author `gorm:"foreignKey:AuthorId"`
+}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "schema.db") + "?_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestVerifyMatchesAutoMigrate(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.AutoMigrate(&author{}, &post{}); err != nil {
+		t.Fatal(err)
+	}
+	RequireMatch(t, db, &author{}, &post{})
+}
+
+func TestVerifyReportsMismatches(t *testing.T) {
+	db := openSQLite(t)
+	statements := []string{
+		"CREATE TABLE authors (id INTEGER PRIMARY KEY AUTOINCREMENT, name varchar(255) NOT NULL UNIQUE, created_at datetime, nickname text)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, author_id integer NOT NULL, title text, edited_at datetime)",
+		"CREATE INDEX idx_author_id ON posts (author_id)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mismatches, err := Verify(db, &author{}, &post{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mismatch{
+		{Table: "authors", Kind: KindColumn, Name: "nickname", Actual: "nickname"},
+		{Table: "posts", Kind: KindType, Name: "title", Expected: "varchar(255)", Actual: "text"},
+		{Table: "posts", Kind: KindNullable, Name: "title", Expected: "NOT NULL", Actual: "NULL"},
+		{Table: "posts", Kind: KindIndex, Name: "idx_title", Expected: "(title)"},
+		{Table: "posts", Kind: KindIndex, Name: "idx_author_id", Actual: "(author_id)"},
+		{Table: "posts", Kind: KindForeignKey, Name: "author_id", Expected: "author_id -> authors.id"},
+	}
+	for _, mismatch := range want {
+		if !slices.Contains(mismatches, mismatch) {
+			t.Errorf("missing mismatch %s", mismatch)
+		}
+	}
+	if len(mismatches) != len(want) {
+		t.Errorf("Verify() = %v, want %v", mismatches, want)
+	}
+}
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       string
+	}{
+		{columnType: "int(11)", want: "int"},
+		{columnType: "INT AUTO_INCREMENT", want: "int"},
+		{columnType: "integer PRIMARY KEY AUTOINCREMENT", want: "int"},
+		{columnType: "bigint unsigned", want: "bigint"},
+		{columnType: "datetime(3) NULL", want: "datetime(3)"},
+		{columnType: "timestamp(3)", want: "datetime(3)"},
+		{columnType: "varchar(255)", want: "varchar(255)"},
+		{columnType: "decimal(10, 2)", want: "decimal(10,2)"},
+	}
+	for _, test := range tests {
+		t.Run(test.columnType, func(t *testing.T) {
+			if got := normalizeType(test.columnType); got != test.want {
+				t.Fatalf("normalizeType(%q) = %q, want %q", test.columnType, got, test.want)
+			}
+		})
+	}
+}
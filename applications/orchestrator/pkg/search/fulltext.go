@@ -0,0 +1,46 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const matchContent = "MATCH (messages.content) AGAINST (? IN BOOLEAN MODE)"
+
+type fullTextIndex struct {
+	db *gorm.DB
+}
+
+// NewFullTextIndex return an index backed by the FULLTEXT index of messages.content, every term is a required
+// phrase like the substring match of the LIKE index, and hits are ranked by the MySQL relevance
+func NewFullTextIndex(db *gorm.DB) SearchIndex {
+	return &fullTextIndex{db: db}
+}
+
+func (i *fullTextIndex) Search(ctx context.Context, query Query) ([]Hit, error) {
+	expression := booleanQuery(Terms(query.Text))
+	hits := make([]Hit, 0)
+	err := readable(i.db.WithContext(ctx), query).
+		Select("messages.id AS message_id, "+matchContent+" AS score", expression).
+		Where(matchContent, expression).
+		Order("score DESC").
+		Order("messages.id DESC").
+		Scan(&hits).Error
+	return hits, err
+}
+
+// booleanQuery requires each term as a phrase, the ngram parser then matches the consecutive ngrams of the
+// term instead of any of them. A phrase has no escape, its quotes are dropped and the operators inside it
+// are plain characters
+func booleanQuery(terms []string) string {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(strings.ReplaceAll(term, `"`, " "))
+		if term != "" {
+			phrases = append(phrases, `+"`+term+`"`)
+		}
+	}
+	return strings.Join(phrases, " ")
+}
@@ -0,0 +1,26 @@
+package search
+
+import "testing"
+
+func TestBooleanQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  string
+	}{
+		{name: "no term", terms: []string{}, want: ""},
+		{name: "single term", terms: []string{"hello"}, want: `+"hello"`},
+		{name: "every term is required", terms: []string{"hello", "world"}, want: `+"hello" +"world"`},
+		{name: "operators stay inside the phrase", terms: []string{"-foo*", "+bar", "(baz)", "~a<b>"}, want: `+"-foo*" +"+bar" +"(baz)" +"~a<b>"`},
+		{name: "quotes are dropped", terms: []string{`say"hi`, `"quoted"`}, want: `+"say hi" +"quoted"`},
+		{name: "a lone quote is skipped", terms: []string{`"`, "x"}, want: `+"x"`},
+		{name: "ngram languages", terms: []string{"こんにちは"}, want: `+"こんにちは"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := booleanQuery(test.terms); got != test.want {
+				t.Fatalf("booleanQuery(%q) = %s, want %s", test.terms, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+const ellipsis = "…"
+
+// Range is a highlighted part of a snippet, in characters from Start included to End excluded
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Snippet is the part of a content around the first matching term
+type Snippet struct {
+	Text       string  `json:"text"`
+	Highlights []Range `json:"highlights"`
+}
+
+// Terms return the lower cased words of a query text
+func Terms(text string) []string {
+	return strings.Fields(strings.ToLower(text))
+}
+
+// Highlight return a snippet of at most length characters around the first term found in the content,
+// with every occurrence of the terms highlighted. The content is cut on its start when no term is found
+func Highlight(content string, terms []string, length int) Snippet {
+	runes := []rune(content)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	matches := make([]Range, 0)
+	for i := 0; i < len(lower); {
+		end := matchAt(lower, i, terms)
+		if end > i {
+			matches = append(matches, Range{Start: i, End: end})
+			i = end
+			continue
+		}
+		i++
+	}
+
+	start := 0
+	if len(matches) > 0 && len(runes) > length {
+		// the first match is kept at about a third of the snippet
+		start = max(0, min(matches[0].Start-length/3, len(runes)-length))
+	}
+	end := min(len(runes), start+length)
+
+	var text strings.Builder
+	offset := start
+	if start > 0 {
+		text.WriteString(ellipsis)
+		offset--
+	}
+	text.WriteString(string(runes[start:end]))
+	if end < len(runes) {
+		text.WriteString(ellipsis)
+	}
+
+	highlights := make([]Range, 0, len(matches))
+	for _, match := range matches {
+		if match.Start >= start && match.End <= end {
+			highlights = append(highlights, Range{Start: match.Start - offset, End: match.End - offset})
+		}
+	}
+	return Snippet{Text: text.String(), Highlights: highlights}
+}
+
+// matchAt return the end of the longest term found at position i, i when there is none
+func matchAt(content []rune, i int, terms []string) int {
+	end := i
+	for _, term := range terms {
+		termRunes := []rune(term)
+		if i+len(termRunes) > len(content) || i+len(termRunes) <= end {
+			continue
+		}
+		if string(content[i:i+len(termRunes)]) == term {
+			end = i + len(termRunes)
+		}
+	}
+	return end
+}
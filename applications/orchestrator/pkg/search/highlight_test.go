@@ -0,0 +1,133 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		terms      []string
+		length     int
+		want       string
+		highlights []Range
+	}{
+		{
+			name:    "no match",
+			content: "hello world",
+			terms:   []string{"foo"},
+			length:  20,
+			want:    "hello world",
+		},
+		{
+			name:    "no match in a long content is cut on its start",
+			content: "abcdefghij",
+			terms:   []string{"x"},
+			length:  5,
+			want:    "abcde…",
+		},
+		{
+			name:       "match at the start",
+			content:    "hello world",
+			terms:      []string{"hello"},
+			length:     20,
+			want:       "hello world",
+			highlights: []Range{{Start: 0, End: 5}},
+		},
+		{
+			name:       "case insensitive",
+			content:    "Hello HELLO",
+			terms:      []string{"hello"},
+			length:     20,
+			want:       "Hello HELLO",
+			highlights: []Range{{Start: 0, End: 5}, {Start: 6, End: 11}},
+		},
+		{
+			name:       "leading ellipsis shifts the offsets",
+			content:    "aaaaaaaaaaaaaaaaaaaa target bbbb",
+			terms:      []string{"target"},
+			length:     12,
+			want:       "…aaa target b…",
+			highlights: []Range{{Start: 5, End: 11}},
+		},
+		{
+			name:       "match near the end keeps the snippet full",
+			content:    "aaaaaaaaaa end",
+			terms:      []string{"end"},
+			length:     6,
+			want:       "…aa end",
+			highlights: []Range{{Start: 4, End: 7}},
+		},
+		{
+			name:       "match cut at the snippet end is not highlighted",
+			content:    "find me and find me again",
+			terms:      []string{"find"},
+			length:     14,
+			want:       "find me and fi…",
+			highlights: []Range{{Start: 0, End: 4}},
+		},
+		{
+			name:       "several terms",
+			content:    "red fish blue fish",
+			terms:      []string{"fish", "red"},
+			length:     20,
+			want:       "red fish blue fish",
+			highlights: []Range{{Start: 0, End: 3}, {Start: 4, End: 8}, {Start: 14, End: 18}},
+		},
+		{
+			name:       "longest overlapping term wins",
+			content:    "notebook",
+			terms:      []string{"note", "notebook", "book"},
+			length:     20,
+			want:       "notebook",
+			highlights: []Range{{Start: 0, End: 8}},
+		},
+		{
+			name:       "offsets are in characters",
+			content:    "Ça va? ÇA VA!",
+			terms:      []string{"ça"},
+			length:     20,
+			want:       "Ça va? ÇA VA!",
+			highlights: []Range{{Start: 0, End: 2}, {Start: 7, End: 9}},
+		},
+		{
+			name:       "ngram languages",
+			content:    "今日は東京へ行く",
+			terms:      []string{"東京"},
+			length:     20,
+			want:       "今日は東京へ行く",
+			highlights: []Range{{Start: 3, End: 5}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Highlight(test.content, test.terms, test.length)
+			if got.Text != test.want {
+				t.Fatalf("text = %q, want %q", got.Text, test.want)
+			}
+			if !slices.Equal(got.Highlights, test.highlights) {
+				t.Fatalf("highlights = %v, want %v", got.Highlights, test.highlights)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "", want: []string{}},
+		{text: " Hello  WORLD\t", want: []string{"hello", "world"}},
+		{text: "ÇA va", want: []string{"ça", "va"}},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Terms(test.text); !slices.Equal(got, test.want) {
+				t.Fatalf("Terms(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type likeIndex struct {
+	db *gorm.DB
+}
+
+// NewLikeIndex return an index matching every term of the query as a substring of the content, it needs
+// no index but scans the messages and ranks the newest first
+func NewLikeIndex(db *gorm.DB) SearchIndex {
+	return &likeIndex{db: db}
+}
+
+func (i *likeIndex) Search(ctx context.Context, query Query) ([]Hit, error) {
+	db := readable(i.db.WithContext(ctx), query).
+		Select("messages.id AS message_id, 0 AS score")
+	for _, term := range Terms(query.Text) {
+		db = db.Where(`LOWER(messages.content) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(term)+"%")
+	}
+
+	hits := make([]Hit, 0)
+	err := db.Order("messages.id DESC").Scan(&hits).Error
+	return hits, err
+}
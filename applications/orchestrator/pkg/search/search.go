@@ -0,0 +1,67 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Query is a search through the messages of the conversations a user belongs to, the optional
+// filters narrow it to a conversation, a sender and a creation time range
+type Query struct {
+	UserIdentification string
+	Text               string
+	ConversationId     *int64
+	Sender             *string
+	Since              *time.Time
+	Until              *time.Time
+	Offset             int
+	Limit              int
+}
+
+// Hit is a message matching a query, hits are ranked by descending score
+type Hit struct {
+	MessageId int     `gorm:"column:message_id"`
+	Score     float64 `gorm:"column:score"`
+}
+
+// SearchIndex finds the messages matching a query, deleted messages are left out. It only return ids
+// so that an index kept outside of the database can be swapped in
+type SearchIndex interface {
+	Search(ctx context.Context, query Query) ([]Hit, error)
+}
+
+// NewSearchIndex return the index of the database dialect, the FULLTEXT index with MySQL and a
+// substring match with SQLite
+func NewSearchIndex(db *gorm.DB) SearchIndex {
+	if db.Dialector.Name() == "sqlite" {
+		return NewLikeIndex(db)
+	}
+	return NewFullTextIndex(db)
+}
+
+// readable selects the messages of the query the user can read
+func readable(db *gorm.DB, query Query) *gorm.DB {
+	db = db.Table("messages").
+		Joins("JOIN user_conversations ON user_conversations.conversation_id = messages.conversation_id AND user_conversations.user_identification = ?", query.UserIdentification).
+		Where("messages.deleted_at IS NULL")
+
+	if query.ConversationId != nil {
+		db = db.Where("messages.conversation_id = ?", *query.ConversationId)
+	}
+
+	if query.Sender != nil {
+		db = db.Where("messages.sender = ?", *query.Sender)
+	}
+
+	if query.Since != nil {
+		db = db.Where("messages.created_at >= ?", *query.Since)
+	}
+
+	if query.Until != nil {
+		db = db.Where("messages.created_at < ?", *query.Until)
+	}
+
+	return db.Offset(query.Offset).Limit(query.Limit)
+}
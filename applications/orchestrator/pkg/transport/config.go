@@ -0,0 +1,46 @@
+package transport
+
+import "time"
+
+const (
+	// KindPubSub publishes to Redis pub/sub channels, a node that is not subscribed misses the messages
+	KindPubSub = "pubsub"
+	// KindStreams appends to Redis streams read through a consumer group, messages wait for the node
+	KindStreams = "streams"
+)
+
+// Config hold the transport used between the outbox relay and the streamer nodes
+type Config struct {
+	Kind    string        `json:"kind" mapstructure:"kind" yaml:"kind"`
+	Streams StreamsConfig `json:"streams" mapstructure:"streams" yaml:"streams"`
+}
+
+type StreamsConfig struct {
+	// MaxLen bounds every node stream, older entries are trimmed approximately
+	MaxLen int64  `json:"max_len" mapstructure:"max_len" yaml:"max_len"`
+	Group  string `json:"group" mapstructure:"group" yaml:"group"`
+	// BatchSize is the number of entries read or reclaimed at once
+	BatchSize    int64         `json:"batch_size" mapstructure:"batch_size" yaml:"batch_size"`
+	BlockTimeout time.Duration `json:"block_timeout" mapstructure:"block_timeout" yaml:"block_timeout"`
+	// pending entries idle for ClaimMinIdle are reclaimed every ClaimInterval, and dropped once
+	// delivered MaxDeliveries times
+	ClaimMinIdle  time.Duration `json:"claim_min_idle" mapstructure:"claim_min_idle" yaml:"claim_min_idle"`
+	ClaimInterval time.Duration `json:"claim_interval" mapstructure:"claim_interval" yaml:"claim_interval"`
+	MaxDeliveries int64         `json:"max_deliveries" mapstructure:"max_deliveries" yaml:"max_deliveries"`
+}
+
+// DefaultConfig return a default transport config, pub/sub unless streams are selected
+func DefaultConfig() Config {
+	return Config{
+		Kind: KindPubSub,
+		Streams: StreamsConfig{
+			MaxLen:        10000,
+			Group:         "streamer",
+			BatchSize:     100,
+			BlockTimeout:  5 * time.Second,
+			ClaimMinIdle:  30 * time.Second,
+			ClaimInterval: 15 * time.Second,
+			MaxDeliveries: 5,
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/YumikoKawaii/shared/pubsub"
+)
+
+const memoryBufferSize = 1024
+
+// Memory is a publisher and subscriber kept in process, for a single binary running every service.
+// Like Redis pub/sub, a message published to a topic nobody consumes is dropped
+type Memory struct {
+	mu     sync.RWMutex
+	topics map[string]map[*memorySubscription]struct{}
+}
+
+type memorySubscription struct {
+	messages chan []byte
+	// done is closed once the consumer returned, publishers stop waiting on it
+	done chan struct{}
+}
+
+var (
+	_ pubsub.Publisher  = (*Memory)(nil)
+	_ pubsub.Subscriber = (*Memory)(nil)
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		topics: make(map[string]map[*memorySubscription]struct{}),
+	}
+}
+
+// Publish hands the payload to every consumer of the topic, waiting while a consumer buffer is full
+func (m *Memory) Publish(ctx context.Context, topic string, bytes []byte) error {
+	m.mu.RLock()
+	subscriptions := make([]*memorySubscription, 0, len(m.topics[topic]))
+	for subscription := range m.topics[topic] {
+		subscriptions = append(subscriptions, subscription)
+	}
+	m.mu.RUnlock()
+
+	for _, subscription := range subscriptions {
+		select {
+		case subscription.messages <- bytes:
+		case <-subscription.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Consume calls fn for every payload published to the topic until ctx is done
+func (m *Memory) Consume(ctx context.Context, topic string, fn pubsub.HandleMessageFn) {
+	subscription := &memorySubscription{
+		messages: make(chan []byte, memoryBufferSize),
+		done:     make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	if _, ok := m.topics[topic]; !ok {
+		m.topics[topic] = make(map[*memorySubscription]struct{})
+	}
+	m.topics[topic][subscription] = struct{}{}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.topics[topic], subscription)
+		m.mu.Unlock()
+		close(subscription.done)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case bytes := <-subscription.messages:
+			if err := fn(bytes); err != nil {
+				logger.WithFields(logger.Fields{
+					"error": err,
+					"topic": topic,
+				}).Errorf("Failed to handle message")
+			}
+		}
+	}
+}
+
+// Close is a no-op, Consume returns once its context is done
+func (m *Memory) Close(_ context.Context) error {
+	return nil
+}
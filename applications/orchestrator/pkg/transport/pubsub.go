@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/YumikoKawaii/shared/pubsub"
+	v9 "github.com/redis/go-redis/v9"
+)
+
+// pubSubPublisher publishes to Redis pub/sub channels, unlike the shared publisher it reports the
+// failed publishes so that the relay retries them
+type pubSubPublisher struct {
+	client *v9.Client
+}
+
+func NewPubSubPublisher(client *v9.Client) pubsub.Publisher {
+	return &pubSubPublisher{client: client}
+}
+
+func (p *pubSubPublisher) Publish(ctx context.Context, topic string, bytes []byte) error {
+	return p.client.Publish(ctx, topic, bytes).Err()
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/YumikoKawaii/shared/pubsub"
+	v9 "github.com/redis/go-redis/v9"
+)
+
+const (
+	payloadField = "payload"
+	// retryDelay is the pause after a failed read before trying again
+	retryDelay = time.Second
+)
+
+type streamsPublisher struct {
+	cfg    StreamsConfig
+	client *v9.Client
+}
+
+func NewStreamsPublisher(cfg StreamsConfig, client *v9.Client) pubsub.Publisher {
+	return &streamsPublisher{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// Publish appends the payload to the stream named after the topic, unlike pub/sub the error is returned
+// so that the relay retries. The stream is created by its consumer, a publish to the stream of a node
+// that was swept is dropped rather than bringing the stream back
+func (p *streamsPublisher) Publish(ctx context.Context, topic string, bytes []byte) error {
+	err := p.client.XAdd(ctx, &v9.XAddArgs{
+		Stream:     topic,
+		NoMkStream: true,
+		MaxLen:     p.cfg.MaxLen,
+		Approx:     true,
+		Values:     map[string]interface{}{payloadField: bytes},
+	}).Err()
+	// XADD replies nil when the stream is missing
+	if errors.Is(err, v9.Nil) {
+		return nil
+	}
+	return err
+}
+
+type streamsSubscriber struct {
+	cfg      StreamsConfig
+	client   *v9.Client
+	consumer string
+}
+
+func NewStreamsSubscriber(cfg StreamsConfig, client *v9.Client, consumer string) pubsub.Subscriber {
+	return &streamsSubscriber{
+		cfg:      cfg,
+		client:   client,
+		consumer: consumer,
+	}
+}
+
+// Consume reads the stream through the consumer group until ctx is done, an entry is acked once fn
+// succeeds and stays pending otherwise until it is reclaimed
+func (s *streamsSubscriber) Consume(ctx context.Context, topic string, fn pubsub.HandleMessageFn) {
+	if err := s.client.XGroupCreateMkStream(ctx, topic, s.cfg.Group, "$").Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		logger.WithFields(logger.Fields{
+			"error":  err,
+			"stream": topic,
+		}).Errorf("Failed to create consumer group")
+		return
+	}
+
+	// entries left pending by an earlier read of this consumer are handled first
+	lastClaim := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= s.cfg.ClaimInterval {
+			s.reclaim(ctx, topic, fn)
+			lastClaim = time.Now()
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &v9.XReadGroupArgs{
+			Group:    s.cfg.Group,
+			Consumer: s.consumer,
+			Streams:  []string{topic, ">"},
+			Count:    s.cfg.BatchSize,
+			Block:    s.cfg.BlockTimeout,
+		}).Result()
+		if errors.Is(err, v9.Nil) || ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"error":  err,
+				"stream": topic,
+			}).Errorf("Failed to read stream")
+			time.Sleep(retryDelay)
+			continue
+		}
+
+		for _, stream := range streams {
+			s.handle(ctx, topic, stream.Messages, fn)
+		}
+	}
+}
+
+// reclaim takes over the entries pending for longer than ClaimMinIdle, whichever consumer they were
+// delivered to, entries delivered MaxDeliveries times are acked without being handled
+func (s *streamsSubscriber) reclaim(ctx context.Context, topic string, fn pubsub.HandleMessageFn) {
+	pending, err := s.client.XPendingExt(ctx, &v9.XPendingExtArgs{
+		Stream: topic,
+		Group:  s.cfg.Group,
+		Idle:   s.cfg.ClaimMinIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  s.cfg.BatchSize,
+	}).Result()
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":  err,
+			"stream": topic,
+		}).Errorf("Failed to list pending entries")
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, entry := range pending {
+		if entry.RetryCount >= s.cfg.MaxDeliveries {
+			logger.WithFields(logger.Fields{
+				"stream":     topic,
+				"entry_id":   entry.ID,
+				"deliveries": entry.RetryCount,
+			}).Warnf("Entry delivered too many times, dropping")
+			s.ack(ctx, topic, entry.ID)
+			continue
+		}
+		ids = append(ids, entry.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	messages, err := s.client.XClaim(ctx, &v9.XClaimArgs{
+		Stream:   topic,
+		Group:    s.cfg.Group,
+		Consumer: s.consumer,
+		MinIdle:  s.cfg.ClaimMinIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error":  err,
+			"stream": topic,
+		}).Errorf("Failed to claim pending entries")
+		return
+	}
+	s.handle(ctx, topic, messages, fn)
+}
+
+func (s *streamsSubscriber) handle(ctx context.Context, topic string, messages []v9.XMessage, fn pubsub.HandleMessageFn) {
+	for _, message := range messages {
+		payload, ok := message.Values[payloadField].(string)
+		if !ok {
+			logger.WithFields(logger.Fields{
+				"stream":   topic,
+				"entry_id": message.ID,
+			}).Warnf("Entry without payload, dropping")
+			s.ack(ctx, topic, message.ID)
+			continue
+		}
+
+		if err := fn([]byte(payload)); err != nil {
+			logger.WithFields(logger.Fields{
+				"error":    err,
+				"stream":   topic,
+				"entry_id": message.ID,
+			}).Errorf("Failed to handle entry, leaving it pending")
+			continue
+		}
+		s.ack(ctx, topic, message.ID)
+	}
+}
+
+func (s *streamsSubscriber) ack(ctx context.Context, topic string, id string) {
+	if err := s.client.XAck(ctx, topic, s.cfg.Group, id).Err(); err != nil {
+		logger.WithFields(logger.Fields{
+			"error":    err,
+			"stream":   topic,
+			"entry_id": id,
+		}).Errorf("Failed to ack entry")
+	}
+}
+
+// Close is a no-op, Consume returns once its context is done
+func (s *streamsSubscriber) Close(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	v9 "github.com/redis/go-redis/v9"
+)
+
+func TestStreamsPublish(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := v9.NewClient(&v9.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	publisher := NewStreamsPublisher(DefaultConfig().Streams, client)
+
+	// the stream of a swept node is gone, the publish is dropped without bringing it back
+	if err := publisher.Publish(ctx, "messages.swept", []byte("frame")); err != nil {
+		t.Fatalf("Publish() to a missing stream = %s, want nil", err)
+	}
+	if server.Exists("messages.swept") {
+		t.Fatal("Publish() created the stream")
+	}
+
+	if err := client.XGroupCreateMkStream(ctx, "messages.alive", "orchestrator", "$").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish(ctx, "messages.alive", []byte("frame")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := client.XRange(ctx, "messages.alive", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Values[payloadField] != "frame" {
+		t.Fatalf("stream holds %v, want the published frame", entries)
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YumikoKawaii/shared/pubsub"
+	"github.com/YumikoKawaii/shared/redis"
+	v9 "github.com/redis/go-redis/v9"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
+)
+
+// NewPublisher return the publisher of the configured kind
+func NewPublisher(cfg Config, client *v9.Client) (pubsub.Publisher, error) {
+	switch cfg.Kind {
+	case KindPubSub:
+		return NewPubSubPublisher(client), nil
+	case KindStreams:
+		return NewStreamsPublisher(cfg.Streams, client), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", cfg.Kind)
+	}
+}
+
+// Releaser frees what the transport keeps for a node once the registry swept it
+type Releaser func(ctx context.Context, nodeId string) error
+
+// NewReleaser return the releaser of the configured kind, a node stream is deleted with its consumer
+// group and pending entries while pub/sub keeps nothing per node
+func NewReleaser(cfg Config, client *v9.Client) (Releaser, error) {
+	switch cfg.Kind {
+	case KindPubSub:
+		return func(context.Context, string) error { return nil }, nil
+	case KindStreams:
+		return func(ctx context.Context, nodeId string) error {
+			return client.Del(ctx, constants.GenerateMessagesTopic(nodeId)).Err()
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", cfg.Kind)
+	}
+}
+
+// NewSubscriber return the subscriber of the configured kind, consumer names this node in the consumer group
+func NewSubscriber(cfg Config, client *v9.Client, consumer string) (pubsub.Subscriber, error) {
+	switch cfg.Kind {
+	case KindPubSub:
+		return redis.NewSubscriber(client), nil
+	case KindStreams:
+		return NewStreamsSubscriber(cfg.Streams, client, consumer), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,112 @@
+package serve
+
+import (
+	"context"
+
+	"github.com/YumikoKawaii/shared/logger"
+	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"yumiko_kawaii.com/yine/applications/orchestrator/config"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/receiver"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/relay"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/streamer"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/database"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/search"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/transport"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// ServeAllInOne runs the receiver and the streamer in one process on the same server, with the registry
+// and the transport kept in memory and no tracing. With the sqlite database driver it needs no external service
+func ServeAllInOne(_ *cobra.Command, _ []string) {
+	conf, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	logger.Infof("Starting all-in-one initialization")
+
+	ctx := context.Background()
+	tracer := noop.NewTracerProvider().Tracer("")
+	traceInterceptor := interceptor.NewTracer(tracer)
+	// a local run may disable auth explicitly with authcfg.enabled set to false
+	var authInterceptor interceptor.Authenticator
+	if conf.AuthCfg.Enabled {
+		authInterceptor, err = interceptor.NewAuthenticator(conf.AuthCfg)
+		if err != nil {
+			logger.Fatalf("error initializing authenticator: %s", err.Error())
+		}
+	} else {
+		authInterceptor = interceptor.NewNoopAuthenticator()
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		grpc_prometheus.UnaryServerInterceptor,
+		grpc_validator.UnaryServerInterceptor(),
+		grpc_recovery.UnaryServerInterceptor(),
+		traceInterceptor.Unary,
+		authInterceptor.Unary,
+	}
+
+	s := server.NewServer(conf.Server,
+		grpc.KeepaliveParams(keepalive.ServerParameters{}),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(
+			grpc_prometheus.StreamServerInterceptor,
+			grpc_validator.StreamServerInterceptor(),
+			grpc_recovery.StreamServerInterceptor(),
+			traceInterceptor.Stream,
+			authInterceptor.Stream,
+		),
+	)
+
+	s.UseRouteInterceptors(unaryInterceptors...)
+
+	logger.WithFields(logger.Fields{
+		"driver": conf.DatabaseCfg.Driver,
+	}).Infof("Initializing database")
+	db, err := database.Open(conf.DatabaseCfg, &conf.MysqlCfg)
+	if err != nil {
+		logger.Fatalf("error opening database: %s", err.Error())
+	}
+	dbWorker := uow.New(db)
+	connectionRegistry := connection_registry.NewMemoryRegistry()
+	messageTransport := transport.NewMemory()
+
+	outboxRelay := relay.NewRelay(conf.RelayCfg, connectionRegistry, messageTransport, dbWorker, tracer)
+	go outboxRelay.Run(ctx)
+	blobs := newBlobStore(ctx, conf.BlobStoreCfg, s)
+	receiverSrv := receiver.NewHandler(conf.ReceiverCfg, dbWorker, authorization.NewAuthorizer(), blobs, messagetype.NewRegistry(conf.ContentCfg), search.NewSearchIndex(db))
+	streamerSrv := streamer.NewHandler(conf.StreamerCfg, connectionRegistry, messageTransport, dbWorker, tracer)
+	streamerSrv.Start(ctx)
+
+	logger.Infof("Registering gRPC services")
+	if err = s.Register(
+		receiverSrv,
+		streamerSrv,
+	); err != nil {
+		logger.WithFields(logger.Fields{"error": err}).Fatalf("Error registering servers")
+	}
+
+	logger.WithFields(logger.Fields{
+		"grpc_addr": conf.Server.GRPC.Host,
+		"grpc_port": conf.Server.GRPC.Port,
+		"http_addr": conf.Server.HTTP.Host,
+		"http_port": conf.Server.HTTP.Port,
+		"node_id":   conf.StreamerCfg.NodeId,
+	}).Infof("Starting all-in-one server")
+
+	if err = s.Serve(); err != nil {
+		logger.WithFields(logger.Fields{"error": err}).Fatalf("Error starting server")
+	}
+}
@@ -0,0 +1,28 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/blobstore"
+	"yumiko_kawaii.com/yine/applications/orchestrator/server"
+)
+
+// newBlobStore opens the configured blob store and mounts its signed URLs on the server when it serves them itself
+func newBlobStore(ctx context.Context, cfg blobstore.Config, s *server.Server) blobstore.BlobStore {
+	blobs, err := blobstore.NewBlobStore(ctx, cfg)
+	if err != nil {
+		logger.Fatalf("error initializing blob store: %s", err.Error())
+	}
+
+	if urlServer, ok := blobs.(blobstore.URLServer); ok {
+		if err := s.HandlePath(http.MethodPut, blobstore.BlobPattern, urlServer.ServeUpload); err != nil {
+			logger.Fatalf("error mounting blob uploads: %s", err.Error())
+		}
+		if err := s.HandlePath(http.MethodGet, blobstore.BlobPattern, urlServer.ServeDownload); err != nil {
+			logger.Fatalf("error mounting blob downloads: %s", err.Error())
+		}
+	}
+	return blobs
+}
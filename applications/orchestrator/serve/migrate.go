@@ -0,0 +1,82 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/YumikoKawaii/shared/mysql"
+	"github.com/spf13/cobra"
+	"yumiko_kawaii.com/yine/applications/orchestrator/config"
+	"yumiko_kawaii.com/yine/applications/orchestrator/migrations"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/database"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/migrator"
+)
+
+func MigrateUp(_ *cobra.Command, _ []string) {
+	if err := newMigrator().Up(context.Background()); err != nil {
+		logger.Fatalf("error applying migrations: %s", err.Error())
+	}
+	logger.Infof("Migrations applied")
+}
+
+func MigrateDown(_ *cobra.Command, _ []string) {
+	if err := newMigrator().Down(context.Background()); err != nil {
+		logger.Fatalf("error reverting migration: %s", err.Error())
+	}
+}
+
+func MigrateTo(_ *cobra.Command, args []string) {
+	if err := newMigrator().To(context.Background(), args[0]); err != nil {
+		logger.Fatalf("error migrating to %s: %s", args[0], err.Error())
+	}
+	logger.WithFields(logger.Fields{
+		"version": args[0],
+	}).Infof("Migrated")
+}
+
+func MigrateStatus(_ *cobra.Command, _ []string) {
+	statuses, err := newMigrator().Status(context.Background())
+	if err != nil {
+		logger.Fatalf("error reading migration status: %s", err.Error())
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Drifted {
+			state = "drifted"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", status.Version, state, appliedAt)
+	}
+	_ = w.Flush()
+}
+
+// newMigrator return a migrator of the embedded migrations on the configured MySQL database
+func newMigrator() migrator.Migrator {
+	conf, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+	if conf.DatabaseCfg.Driver != database.DriverMySQL {
+		logger.Fatalf("migrations only run on MySQL, the %s schema is created from the models", conf.DatabaseCfg.Driver)
+	}
+
+	loaded, err := migrator.Load(migrations.FS)
+	if err != nil {
+		logger.Fatalf("error loading migrations: %s", err.Error())
+	}
+
+	sqlDB, err := mysql.Initialize(&conf.MysqlCfg).DB()
+	if err != nil {
+		logger.Fatalf("error connecting database: %s", err.Error())
+	}
+	return migrator.NewMigrator(sqlDB, loaded)
+}
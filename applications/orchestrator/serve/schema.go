@@ -0,0 +1,38 @@
+package serve
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/spf13/cobra"
+	"yumiko_kawaii.com/yine/applications/orchestrator/config"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/database"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/models"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/schemacheck"
+)
+
+// SchemaVerify compares the models with the configured database and exits with 1 on any mismatch
+func SchemaVerify(_ *cobra.Command, _ []string) {
+	conf, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := database.Open(conf.DatabaseCfg, &conf.MysqlCfg)
+	if err != nil {
+		logger.Fatalf("error opening database: %s", err.Error())
+	}
+
+	mismatches, err := schemacheck.Verify(db, models.All()...)
+	if err != nil {
+		logger.Fatalf("error verifying schema: %s", err.Error())
+	}
+	for _, mismatch := range mismatches {
+		fmt.Println(mismatch)
+	}
+	if len(mismatches) > 0 {
+		os.Exit(1)
+	}
+	logger.Infof("Schema matches the models")
+}
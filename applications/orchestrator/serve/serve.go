@@ -10,11 +10,18 @@ import (
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/connection_registry"
 	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/receiver"
+	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/relay"
 	"yumiko_kawaii.com/yine/applications/orchestrator/handlers/streamer"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/authorization"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/interceptor"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/messagetype"
 	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/repository/uow"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/search"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/transport"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
@@ -33,25 +40,40 @@ func ServeReceiver(_ *cobra.Command, _ []string) {
 
 	ctx := context.Background()
 	tracer, err := otel_tracer.Initialize(ctx, &conf.TracerConfig)
+	if err != nil {
+		logger.Fatalf("error initializing tracer: %s", err.Error())
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	logger.Infof("OpenTelemetry tracer initialized")
 
 	traceInterceptor := interceptor.NewTracer(tracer)
+	authInterceptor, err := interceptor.NewAuthenticator(conf.AuthCfg)
+	if err != nil {
+		logger.Fatalf("error initializing authenticator: %s", err.Error())
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		grpc_prometheus.UnaryServerInterceptor,
+		grpc_validator.UnaryServerInterceptor(),
+		grpc_recovery.UnaryServerInterceptor(),
+		traceInterceptor.Unary,
+		authInterceptor.Unary,
+	}
 
 	s := server.NewServer(conf.Server,
 		grpc.KeepaliveParams(keepalive.ServerParameters{}),
-		grpc.ChainUnaryInterceptor(
-			grpc_prometheus.UnaryServerInterceptor,
-			grpc_validator.UnaryServerInterceptor(),
-			grpc_recovery.UnaryServerInterceptor(),
-			traceInterceptor.Unary,
-		),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 		grpc.ChainStreamInterceptor(
 			grpc_prometheus.StreamServerInterceptor,
 			grpc_validator.StreamServerInterceptor(),
 			grpc_recovery.StreamServerInterceptor(),
+			traceInterceptor.Stream,
+			authInterceptor.Stream,
 		),
 	)
 
+	s.UseRouteInterceptors(unaryInterceptors...)
+
 	logger.Infof("Initializing database and Redis connections")
 	db := mysql.Initialize(&conf.MysqlCfg)
 	redisCli, err := redis.Initialize(conf.RedisCfg)
@@ -59,9 +81,15 @@ func ServeReceiver(_ *cobra.Command, _ []string) {
 		logger.Fatalf("error connecting redis: %s", err.Error())
 	}
 	dbWorker := uow.New(db)
-	connectionRegistry := connection_registry.NewRegistry(redisCli)
-	messagePublisher := redis.NewPublisher(redisCli)
-	srv := receiver.NewHandler(connectionRegistry, messagePublisher, dbWorker)
+	connectionRegistry := connection_registry.NewRegistry(redisCli, conf.RegistryCfg)
+	messagePublisher, err := transport.NewPublisher(conf.TransportCfg, redisCli)
+	if err != nil {
+		logger.Fatalf("error initializing transport: %s", err.Error())
+	}
+	outboxRelay := relay.NewRelay(conf.RelayCfg, connectionRegistry, messagePublisher, dbWorker, tracer)
+	go outboxRelay.Run(ctx)
+	blobs := newBlobStore(ctx, conf.BlobStoreCfg, s)
+	srv := receiver.NewHandler(conf.ReceiverCfg, dbWorker, authorization.NewAuthorizer(), blobs, messagetype.NewRegistry(conf.ContentCfg), search.NewSearchIndex(db))
 
 	logger.Infof("Registering gRPC services")
 	if err = s.Register(
@@ -90,21 +118,63 @@ func ServeStreamer(_ *cobra.Command, _ []string) {
 
 	logger.Infof("Starting Streamer service initialization")
 
+	ctx := context.Background()
+	tracer, err := otel_tracer.Initialize(ctx, &conf.TracerConfig)
+	if err != nil {
+		logger.Fatalf("error initializing tracer: %s", err.Error())
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger.Infof("OpenTelemetry tracer initialized")
+
+	traceInterceptor := interceptor.NewTracer(tracer)
+	authInterceptor, err := interceptor.NewAuthenticator(conf.AuthCfg)
+	if err != nil {
+		logger.Fatalf("error initializing authenticator: %s", err.Error())
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		grpc_prometheus.UnaryServerInterceptor,
+		grpc_validator.UnaryServerInterceptor(),
+		grpc_recovery.UnaryServerInterceptor(),
+		traceInterceptor.Unary,
+		authInterceptor.Unary,
+	}
+
 	s := server.NewServer(conf.Server,
 		grpc.KeepaliveParams(keepalive.ServerParameters{}),
-		grpc.ChainUnaryInterceptor(
-			grpc_prometheus.UnaryServerInterceptor,
-			grpc_validator.UnaryServerInterceptor(),
-			grpc_recovery.UnaryServerInterceptor(),
-		),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 		grpc.ChainStreamInterceptor(
 			grpc_prometheus.StreamServerInterceptor,
 			grpc_validator.StreamServerInterceptor(),
 			grpc_recovery.StreamServerInterceptor(),
+			traceInterceptor.Stream,
+			authInterceptor.Stream,
 		),
 	)
 
-	srv := streamer.NewHandler()
+	s.UseRouteInterceptors(unaryInterceptors...)
+
+	logger.Infof("Initializing database and Redis connections")
+	db := mysql.Initialize(&conf.MysqlCfg)
+	redisCli, err := redis.Initialize(conf.RedisCfg)
+	if err != nil {
+		logger.Fatalf("error connecting redis: %s", err.Error())
+	}
+	dbWorker := uow.New(db)
+	conf.StreamerCfg.NodeId = connection_registry.InstanceId(conf.StreamerCfg.NodeId)
+	connectionRegistry := connection_registry.NewRegistry(redisCli, conf.RegistryCfg)
+	messageSubscriber, err := transport.NewSubscriber(conf.TransportCfg, redisCli, conf.StreamerCfg.NodeId)
+	if err != nil {
+		logger.Fatalf("error initializing transport: %s", err.Error())
+	}
+	srv := streamer.NewHandler(conf.StreamerCfg, connectionRegistry, messageSubscriber, dbWorker, tracer)
+	go connection_registry.KeepAlive(ctx, connectionRegistry, conf.StreamerCfg.NodeId, conf.RegistryCfg.HeartbeatInterval)
+	releaser, err := transport.NewReleaser(conf.TransportCfg, redisCli)
+	if err != nil {
+		logger.Fatalf("error initializing transport: %s", err.Error())
+	}
+	go connection_registry.RunSweeper(ctx, connectionRegistry, conf.RegistryCfg.SweepInterval, releaser)
+	srv.Start(ctx)
 
 	logger.Infof("Registering gRPC services")
 	if err = s.Register(
@@ -118,6 +188,7 @@ func ServeStreamer(_ *cobra.Command, _ []string) {
 		"grpc_port": conf.Server.GRPC.Port,
 		"http_addr": conf.Server.HTTP.Host,
 		"http_port": conf.Server.HTTP.Port,
+		"node_id":   conf.StreamerCfg.NodeId,
 	}).Infof("Starting Streamer server")
 
 	if err = s.Serve(); err != nil {
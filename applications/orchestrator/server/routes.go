@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/YumikoKawaii/shared/logger"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Router is implemented by services exposing methods that are not part of the generated gateway,
+// the routes are served on the gateway mux through the same unary interceptors as gRPC
+type Router interface {
+	Routes() []Route
+}
+
+// Route binds an HTTP method and gateway path pattern to a unary method
+type Route struct {
+	Method     string
+	Pattern    string
+	FullMethod string
+	Decode     RouteDecoder
+	Handler    grpc.UnaryHandler
+}
+
+// RouteDecoder builds the request of a route from the HTTP call
+type RouteDecoder func(r *http.Request, pathParams map[string]string) (interface{}, error)
+
+// Unary adapts a typed method to grpc.UnaryHandler
+func Unary[Req any, Resp any](fn func(context.Context, *Req) (*Resp, error)) grpc.UnaryHandler {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return fn(ctx, request.(*Req))
+	}
+}
+
+// DecodeJSON decodes the request body into v
+func DecodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %s", err.Error())
+	}
+	return nil
+}
+
+// HandlePath serves a raw HTTP handler on the gateway mux, it goes through no interceptor and has
+// to authenticate the calls itself
+func (s *Server) HandlePath(method string, pattern string, handler runtime.HandlerFunc) error {
+	return s.mux.HandlePath(method, pattern, handler)
+}
+
+// UseRouteInterceptors sets the interceptors wrapping every route, in the order they are given
+func (s *Server) UseRouteInterceptors(interceptors ...grpc.UnaryServerInterceptor) {
+	s.routeInterceptors = interceptors
+}
+
+func (s *Server) registerRoutes(router Router) error {
+	for _, route := range router.Routes() {
+		if err := s.mux.HandlePath(route.Method, route.Pattern, s.serveRoute(route)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveRoute(route Route) runtime.HandlerFunc {
+	info := &grpc.UnaryServerInfo{FullMethod: route.FullMethod}
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := metadata.NewIncomingContext(r.Context(), headerMetadata(r.Header))
+
+		request, err := route.Decode(r, pathParams)
+		if err != nil {
+			writeRouteError(w, err)
+			return
+		}
+
+		response, err := s.chainRoute(ctx, request, info, route.Handler)
+		if err != nil {
+			writeRouteError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.WithFields(logger.Fields{
+				"error":  err,
+				"method": route.FullMethod,
+			}).Errorf("Failed to write route response")
+		}
+	}
+}
+
+func (s *Server) chainRoute(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	for i := len(s.routeInterceptors) - 1; i >= 0; i-- {
+		interceptor, next := s.routeInterceptors[i], handler
+		handler = func(ctx context.Context, request interface{}) (interface{}, error) {
+			return interceptor(ctx, request, info, next)
+		}
+	}
+	return handler(ctx, request)
+}
+
+// headerMetadata maps HTTP headers to incoming metadata the way the generated gateway does
+func headerMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		if key == "Authorization" {
+			md.Append("authorization", values...)
+		}
+		if mdKey, ok := incomingHeaderMatcher(key); ok {
+			md.Append(strings.ToLower(mdKey), values...)
+		}
+	}
+	return md
+}
+
+func writeRouteError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(s.Code()))
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    s.Code(),
+		"message": s.Message(),
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"yumiko_kawaii.com/yine/applications/orchestrator/pkg/constants"
 )
 
 // DefaultConfig return a default server config
@@ -49,21 +51,38 @@ func (l ServerListen) String() string {
 
 // Server structure
 type Server struct {
-	gRPC *grpc.Server
-	mux  *runtime.ServeMux
-	cfg  Config
+	gRPC              *grpc.Server
+	mux               *runtime.ServeMux
+	cfg               Config
+	routeInterceptors []grpc.UnaryServerInterceptor
 }
 
 func NewServer(cfg Config, opt ...grpc.ServerOption) *Server {
 	return &Server{
 		gRPC: grpc.NewServer(opt...),
-		mux:  runtime.NewServeMux(
-		//gatewayopt.ProtoJSONMarshaler(),
+		mux: runtime.NewServeMux(
+			//gatewayopt.ProtoJSONMarshaler(),
+			runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
 		),
 		cfg: cfg,
 	}
 }
 
+// forwardedHeaders are the HTTP headers passed to the gRPC handlers as metadata
+var forwardedHeaders = map[string]bool{
+	constants.ClientMessageIdMetadataKey: true,
+	constants.ParentMessageIdMetadataKey: true,
+	constants.QuotedMessageIdMetadataKey: true,
+	constants.AttachmentIdsMetadataKey:   true,
+}
+
+func incomingHeaderMatcher(key string) (string, bool) {
+	if lowerKey := strings.ToLower(key); forwardedHeaders[lowerKey] {
+		return lowerKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
 func (s *Server) Register(grpcServer ...interface{}) error {
 	for _, srv := range grpcServer {
 		switch _srv := srv.(type) {
@@ -82,6 +101,11 @@ func (s *Server) Register(grpcServer ...interface{}) error {
 		default:
 			return fmt.Errorf("unknown GRPC Service to register %#v", srv)
 		}
+		if router, ok := srv.(Router); ok {
+			if err := s.registerRoutes(router); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }